@@ -5,8 +5,16 @@ import (
 	"fmt"
 )
 
-// GetQuotation requests a quotation.
+// GetQuotation requests a quotation. If the client is configured WithQuotationCache, quotations of
+// identical requests are reused.
 func (c *Client) GetQuotation(ctx context.Context, city CityCode, req *GetQuotationRequest) (*GetQuotationResponse, error) {
+	if c.quotationCache != nil {
+		return c.cachedQuotation(ctx, city, req)
+	}
+	return c.getQuotation(ctx, city, req)
+}
+
+func (c *Client) getQuotation(ctx context.Context, city CityCode, req *GetQuotationRequest) (*GetQuotationResponse, error) {
 	path := "/v2/quotations"
 	resp := &GetQuotationResponse{}
 	if err := c.post(ctx, city, path, req, resp); err != nil {
@@ -21,6 +29,9 @@ func (c *Client) PlaceOrder(ctx context.Context, city CityCode, req *PlaceOrderR
 	path := "/v2/orders"
 	resp := &PlaceOrderResponse{}
 	if err := c.post(ctx, city, path, req, resp); err != nil {
+		if isUnknownEnum(err) {
+			return resp, err
+		}
 		return nil, err
 	}
 	return resp, nil
@@ -63,3 +74,92 @@ func (c *Client) DriverLocation(ctx context.Context, city CityCode, orderID, dri
 	}
 	return resp, nil
 }
+
+// AddPriorityFee adds a priority fee to an order to make it more attractive to drivers. The fee is
+// added on top of any priority fee added before. Only orders in ASSIGNING_DRIVER accept a priority fee.
+func (c *Client) AddPriorityFee(ctx context.Context, city CityCode, orderID string, fee Money) error {
+	path := fmt.Sprintf("/v2/orders/%s/priority-fee", orderID)
+	return c.post(ctx, city, path, &AddPriorityFeeRequest{PriorityFee: fee}, nil)
+}
+
+// ChangeDriver requests another driver for an order, giving the reason why the current driver is
+// being replaced. The order goes back to ASSIGNING_DRIVER.
+func (c *Client) ChangeDriver(ctx context.Context, city CityCode, orderID, driverID string, reason ChangeDriverReason) error {
+	if driverID == "" {
+		return errRequiredField
+	}
+	if !reason.IsKnown() {
+		return errInvalidParams
+	}
+	path := fmt.Sprintf("/v2/orders/%s/drivers/%s", orderID, driverID)
+	return c.delete(ctx, city, path, &ChangeDriverRequest{Reason: reason}, nil)
+}
+
+// EditOrder changes the stops, deliveries or requester contact of an active order. Changes are
+// validated with the same rules as GetQuotationRequest before being sent.
+func (c *Client) EditOrder(ctx context.Context, city CityCode, orderID string, changes *EditOrderRequest) (*OrderDetailsResponse, error) {
+	if err := changes.Validate(); err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("/v2/orders/%s", orderID)
+	resp := &OrderDetailsResponse{}
+	if err := c.patch(ctx, city, path, changes, resp); err != nil {
+		if isUnknownEnum(err) {
+			return resp, err
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetCityInfo retrieves the cities served in a country with their service types, special requests
+// and locales.
+//
+// The endpoint answers for the market named by X-LLM-Country, which is the whole country for some
+// countries (e.g. SG) and a single city for others (e.g. PH_MNL and PH_CEB). One request is made per
+// market of the country and the cities of all of them are merged, so that a city is not reported
+// missing only because it belongs to another market of the same country.
+func (c *Client) GetCityInfo(ctx context.Context, country CountryCode) ([]CityInfo, error) {
+	cities := AllCountriesByISOCode[country].Cities
+	if len(cities) == 0 {
+		return nil, errInvalidCountry
+	}
+	var result []CityInfo
+	markets := map[LLMCountry]bool{}
+	seen := map[CityCode]bool{}
+	for _, city := range cities {
+		if markets[city.GetLLMCountry()] {
+			continue
+		}
+		markets[city.GetLLMCountry()] = true
+		resp := &GetCityInfoResponse{}
+		if err := c.get(ctx, city, "/v2/cities", nil, resp); err != nil {
+			return nil, err
+		}
+		for _, info := range resp.Cities {
+			if !seen[info.Locode] {
+				seen[info.Locode] = true
+				result = append(result, info)
+			}
+		}
+	}
+	return result, nil
+}
+
+// SetWebhook registers the URL receiving order updates for the market of the city, replacing the
+// previous one. The URL must be an absolute https URL.
+func (c *Client) SetWebhook(ctx context.Context, city CityCode, webhookURL string) error {
+	if err := validateWebhookURL(webhookURL); err != nil {
+		return err
+	}
+	return c.put(ctx, city, "/v2/webhook", &Webhook{URL: webhookURL}, nil)
+}
+
+// GetWebhook retrieves the webhook registered for the market of the city.
+func (c *Client) GetWebhook(ctx context.Context, city CityCode) (*Webhook, error) {
+	resp := &Webhook{}
+	if err := c.get(ctx, city, "/v2/webhook", nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
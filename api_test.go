@@ -0,0 +1,408 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testQuotation is the quotation request of the orders placed by the cassette tests.
+func testQuotation() *GetQuotationRequest {
+	addr := func(s string) AddressTranslations {
+		return AddressTranslations{LocalePhilippinesEN: {DisplayString: s, Country: CityCodePhilippinesManila.GetLLMCountry()}}
+	}
+	return &GetQuotationRequest{
+		ServiceType: ServiceTypeMotorcycle,
+		Stops: []Waypoint{
+			{Location: Location{Lat: "14.5547", Lng: "121.0244"}, Addresses: addr("Ayala Avenue, Makati City, Metro Manila")},
+			{Location: Location{Lat: "14.5764", Lng: "121.0851"}, Addresses: addr("Ortigas Avenue, Pasig City, Metro Manila")},
+		},
+		Deliveries: []DeliveryInfo{
+			{ToStop: 1, Contact: Contact{Name: "Maria Santos", Phone: "+639179876543"}},
+		},
+		RequesterContact: Contact{Name: "Juan dela Cruz", Phone: "+639171234567"},
+	}
+}
+
+// placeTestOrder quotes and places an order of testQuotation in Manila.
+func placeTestOrder(t *testing.T, ctx context.Context, c *Client) *PlaceOrderResponse {
+	t.Helper()
+	req := testQuotation()
+	quote, err := c.GetQuotation(ctx, CityCodePhilippinesManila, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placed, err := c.PlaceOrder(ctx, CityCodePhilippinesManila, &PlaceOrderRequest{
+		QuotedPrice:         Price{Amount: quote.Amount, Currency: quote.Currency},
+		GetQuotationRequest: *req,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return placed
+}
+
+// waitForStatus polls the order until it reaches the status.
+func waitForStatus(t *testing.T, ctx context.Context, c *Client, orderID string, status OrderStatus) *OrderDetailsResponse {
+	t.Helper()
+	for i := 0; i < 60; i++ {
+		details, err := c.OrderDetails(ctx, CityCodePhilippinesManila, orderID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.Status == status {
+			return details
+		}
+		time.Sleep(pollInterval())
+	}
+	t.Fatalf("order %s never reached %s", orderID, status)
+	return nil
+}
+
+func TestOrderLifecycleReplay(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("PlaceOrder", func(t *testing.T) {
+		c := cassetteClient(t, "place_order")
+		req := testQuotation()
+		quote, err := c.GetQuotation(ctx, CityCodePhilippinesManila, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if quote.Amount == "" || quote.Currency != "PHP" {
+			t.Fatalf("quotation = %+v, want a total fee in PHP", quote)
+		}
+		placed, err := c.PlaceOrder(ctx, CityCodePhilippinesManila, &PlaceOrderRequest{
+			QuotedPrice:         Price{Amount: quote.Amount, Currency: quote.Currency},
+			GetQuotationRequest: *req,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if placed.OrderID == "" {
+			t.Fatal("placed order has no ID")
+		}
+		details, err := c.OrderDetails(ctx, CityCodePhilippinesManila, placed.OrderID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.Status != OrderStatusAssigningDriver {
+			t.Errorf("status = %s, want %s", details.Status, OrderStatusAssigningDriver)
+		}
+		if details.Price.Amount != quote.Amount || details.Price.Currency != quote.Currency {
+			t.Errorf("price = %+v, want the quoted %s %s", details.Price, quote.Amount, quote.Currency)
+		}
+	})
+
+	t.Run("CancelOrder", func(t *testing.T) {
+		c := cassetteClient(t, "cancel_order")
+		placed := placeTestOrder(t, ctx, c)
+		if err := c.CancelOrder(ctx, CityCodePhilippinesManila, placed.OrderID); err != nil {
+			t.Fatal(err)
+		}
+		details, err := c.OrderDetails(ctx, CityCodePhilippinesManila, placed.OrderID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.Status != OrderStatusCanceled {
+			t.Errorf("status = %s, want %s", details.Status, OrderStatusCanceled)
+		}
+	})
+
+	t.Run("DriverDetails and DriverLocation", func(t *testing.T) {
+		c := cassetteClient(t, "driver")
+		placed := placeTestOrder(t, ctx, c)
+		details := waitForStatus(t, ctx, c, placed.OrderID, OrderStatusOngoing)
+		if details.DriverID == "" {
+			t.Fatal("ongoing order has no driver")
+		}
+		driver, err := c.DriverDetails(ctx, CityCodePhilippinesManila, placed.OrderID, details.DriverID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if driver.PlateNumber == "" {
+			t.Error("driver has no plate number")
+		}
+		loc, err := c.DriverLocation(ctx, CityCodePhilippinesManila, placed.OrderID, details.DriverID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loc.Location.LatLng(); err != nil || loc.UpdatedAt.IsZero() {
+			t.Errorf("driver location = %+v, want a location and its time", loc)
+		}
+	})
+
+	t.Run("OrderDetails of unknown order", func(t *testing.T) {
+		c := cassetteClient(t, "order_not_found")
+		if _, err := c.OrderDetails(ctx, CityCodePhilippinesManila, "0"); !errors.Is(err, errUnknownError) {
+			t.Errorf("error = %v, want %v", err, errUnknownError)
+		}
+	})
+}
+
+func TestPriorityFeeReplay(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("AddPriorityFee", func(t *testing.T) {
+		c := cassetteClient(t, "add_priority_fee")
+		placed := placeTestOrder(t, ctx, c)
+		before, err := c.OrderDetails(ctx, CityCodePhilippinesManila, placed.OrderID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fee := Money{Amount: "20", Currency: before.Price.Currency}
+		if err := c.AddPriorityFee(ctx, CityCodePhilippinesManila, placed.OrderID, fee); err != nil {
+			t.Fatal(err)
+		}
+		after, err := c.OrderDetails(ctx, CityCodePhilippinesManila, placed.OrderID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := before.Price.Add(fee)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cmp, err := after.Price.Cmp(want); err != nil || cmp != 0 {
+			t.Errorf("price = %+v, want %+v", after.Price, want)
+		}
+	})
+
+	t.Run("AddPriorityFee in another currency", func(t *testing.T) {
+		c := cassetteClient(t, "add_priority_fee_currency")
+		placed := placeTestOrder(t, ctx, c)
+		err := c.AddPriorityFee(ctx, CityCodePhilippinesManila, placed.OrderID, Money{Amount: "5", Currency: "SGD"})
+		if !errors.Is(err, errInvalidCurrency) {
+			t.Errorf("error = %v, want %v", err, errInvalidCurrency)
+		}
+	})
+
+	t.Run("AddPriorityFee once a driver is assigned", func(t *testing.T) {
+		c := cassetteClient(t, "add_priority_fee_rejected")
+		placed := placeTestOrder(t, ctx, c)
+		waitForStatus(t, ctx, c, placed.OrderID, OrderStatusOngoing)
+		err := c.AddPriorityFee(ctx, CityCodePhilippinesManila, placed.OrderID, Money{Amount: "20", Currency: "PHP"})
+		if !errors.Is(err, errInvalidParams) {
+			t.Errorf("error = %v, want %v", err, errInvalidParams)
+		}
+	})
+
+	t.Run("EscalatePriorityFee until a driver is assigned", func(t *testing.T) {
+		c := cassetteClient(t, "escalate_priority_fee")
+		placed := placeTestOrder(t, ctx, c)
+		step := Money{Amount: "10", Currency: "PHP"}
+		result, err := c.EscalatePriorityFee(ctx, CityCodePhilippinesManila, placed.OrderID, PriorityFeeEscalation{
+			Step:     step,
+			Cap:      Money{Amount: "200", Currency: "PHP"},
+			Interval: pollInterval(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Details.Status == OrderStatusAssigningDriver {
+			t.Fatalf("escalation stopped with the order %s", result.Details.Status)
+		}
+		if cmp, err := result.Added.Cmp(step); err != nil || cmp < 0 {
+			t.Errorf("added %+v, want at least one step", result.Added)
+		}
+	})
+
+	t.Run("EscalatePriorityFee until the cap", func(t *testing.T) {
+		c := cassetteClient(t, "escalate_priority_fee_cap")
+		placed := placeTestOrder(t, ctx, c)
+		result, err := c.EscalatePriorityFee(ctx, CityCodePhilippinesManila, placed.OrderID, PriorityFeeEscalation{
+			Step:     Money{Amount: "10", Currency: "PHP"},
+			Cap:      Money{Amount: "15", Currency: "PHP"},
+			Interval: pollInterval(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Added.Amount != "10" || result.Details.Status != OrderStatusAssigningDriver {
+			t.Errorf("result = %s added with the order %s, want 10 added with the order still %s",
+				result.Added.Amount, result.Details.Status, OrderStatusAssigningDriver)
+		}
+	})
+}
+
+func TestOrderChangesReplay(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ChangeDriver", func(t *testing.T) {
+		c := cassetteClient(t, "change_driver")
+		placed := placeTestOrder(t, ctx, c)
+		details := waitForStatus(t, ctx, c, placed.OrderID, OrderStatusOngoing)
+		if err := c.ChangeDriver(ctx, CityCodePhilippinesManila, placed.OrderID, details.DriverID, ChangeDriverReasonLate); err != nil {
+			t.Fatal(err)
+		}
+		details, err := c.OrderDetails(ctx, CityCodePhilippinesManila, placed.OrderID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.Status != OrderStatusAssigningDriver {
+			t.Errorf("status = %s, want %s", details.Status, OrderStatusAssigningDriver)
+		}
+	})
+
+	t.Run("ChangeDriver before a driver is assigned", func(t *testing.T) {
+		c := cassetteClient(t, "change_driver_rejected")
+		placed := placeTestOrder(t, ctx, c)
+		err := c.ChangeDriver(ctx, CityCodePhilippinesManila, placed.OrderID, "80557", ChangeDriverReasonLate)
+		if !errors.Is(err, errInvalidParams) {
+			t.Errorf("error = %v, want %v", err, errInvalidParams)
+		}
+	})
+
+	t.Run("EditOrder", func(t *testing.T) {
+		c := cassetteClient(t, "edit_order")
+		placed := placeTestOrder(t, ctx, c)
+		stops := testQuotation().Stops
+		stops[1].Location = Location{Lat: "14.5995", Lng: "120.9842"}
+		details, err := c.EditOrder(ctx, CityCodePhilippinesManila, placed.OrderID, &EditOrderRequest{
+			Stops:            stops,
+			Deliveries:       []DeliveryInfo{{ToStop: 1, Contact: Contact{Name: "Juan dela Cruz", Phone: "+639171234567"}}},
+			RequesterContact: &Contact{Name: "Maria Santos", Phone: "+639179876543"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.Status != OrderStatusAssigningDriver {
+			t.Errorf("status = %s, want %s", details.Status, OrderStatusAssigningDriver)
+		}
+		if len(details.Stops) != len(stops) || details.Stops[1].Location != stops[1].Location {
+			t.Errorf("stops = %+v, want the drop off moved to %+v", details.Stops, stops[1].Location)
+		}
+	})
+
+	t.Run("EditOrder once canceled", func(t *testing.T) {
+		c := cassetteClient(t, "edit_order_rejected")
+		placed := placeTestOrder(t, ctx, c)
+		if err := c.CancelOrder(ctx, CityCodePhilippinesManila, placed.OrderID); err != nil {
+			t.Fatal(err)
+		}
+		_, err := c.EditOrder(ctx, CityCodePhilippinesManila, placed.OrderID, &EditOrderRequest{
+			RequesterContact: &Contact{Name: "Maria Santos", Phone: "+639179876543"},
+		})
+		if !errors.Is(err, errInvalidParams) {
+			t.Errorf("error = %v, want %v", err, errInvalidParams)
+		}
+	})
+}
+
+func TestOrderChangesValidation(t *testing.T) {
+	ctx := context.Background()
+	c := testClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		return nil, errors.New("unexpected request")
+	}))
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"ChangeDriver without driver", func() error {
+			return c.ChangeDriver(ctx, CityCodePhilippinesManila, "1001", "", ChangeDriverReasonLate)
+		}, errRequiredField},
+		{"ChangeDriver with unknown reason", func() error {
+			return c.ChangeDriver(ctx, CityCodePhilippinesManila, "1001", "21712", "DRIVER_ASLEEP")
+		}, errInvalidParams},
+		{"EditOrder without changes", func() error {
+			_, err := c.EditOrder(ctx, CityCodePhilippinesManila, "1001", &EditOrderRequest{})
+			return err
+		}, errRequiredField},
+		{"EditOrder stops without deliveries", func() error {
+			_, err := c.EditOrder(ctx, CityCodePhilippinesManila, "1001", &EditOrderRequest{Stops: testQuotation().Stops})
+			return err
+		}, errRequiredField},
+		{"EditOrder delivery to the pick up", func() error {
+			_, err := c.EditOrder(ctx, CityCodePhilippinesManila, "1001", &EditOrderRequest{
+				Stops:      testQuotation().Stops,
+				Deliveries: []DeliveryInfo{{ToStop: 0, Contact: Contact{Name: "Juan dela Cruz", Phone: "+639171234567"}}},
+			})
+			return err
+		}, errDeliveryMismatch},
+		{"EditOrder contact without phone", func() error {
+			_, err := c.EditOrder(ctx, CityCodePhilippinesManila, "1001", &EditOrderRequest{RequesterContact: &Contact{Name: "Maria Santos"}})
+			return err
+		}, errRequiredField},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestCityInfoReplay(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("GetCityInfo of every market", func(t *testing.T) {
+		c := cassetteClient(t, "city_info_ph")
+		cities, err := c.GetCityInfo(ctx, CountryCodePhilippines)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var locodes []CityCode
+		for _, city := range cities {
+			locodes = append(locodes, city.Locode)
+			if len(city.ServiceTypes) == 0 {
+				t.Errorf("%s has no service types", city.Locode)
+			}
+		}
+		if want := CountryPhilippines.Cities; !reflect.DeepEqual(locodes, want) {
+			t.Errorf("cities = %v, want %v", locodes, want)
+		}
+		if diff := DiffCatalog(CountryPhilippines, cities); !diff.Empty() {
+			t.Errorf("catalog diff = %+v, want none", diff)
+		}
+	})
+
+	t.Run("GetCityInfo of unknown country", func(t *testing.T) {
+		c := testClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			return nil, errors.New("unexpected request")
+		}))
+		if _, err := c.GetCityInfo(ctx, "XX"); !errors.Is(err, errInvalidCountry) {
+			t.Errorf("error = %v, want %v", err, errInvalidCountry)
+		}
+	})
+}
+
+func TestWebhookReplay(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("SetWebhook and GetWebhook", func(t *testing.T) {
+		c := cassetteClient(t, "webhook")
+		for _, hookURL := range []string{"https://example.com/lalamove/webhook", "https://example.com/lalamove/webhook/v2"} {
+			if err := c.SetWebhook(ctx, CityCodePhilippinesManila, hookURL); err != nil {
+				t.Fatal(err)
+			}
+			hook, err := c.GetWebhook(ctx, CityCodePhilippinesManila)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hook.URL != hookURL {
+				t.Errorf("webhook = %s, want %s", hook.URL, hookURL)
+			}
+		}
+	})
+
+	t.Run("GetWebhook of another market", func(t *testing.T) {
+		c := cassetteClient(t, "webhook_not_found")
+		if _, err := c.GetWebhook(ctx, CityCodePhilippinesCebu); !errors.Is(err, errUnknownError) {
+			t.Errorf("error = %v, want %v", err, errUnknownError)
+		}
+	})
+
+	t.Run("SetWebhook over http", func(t *testing.T) {
+		c := testClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			return nil, errors.New("unexpected request")
+		}))
+		if err := c.SetWebhook(ctx, CityCodePhilippinesManila, "http://example.com/lalamove/webhook"); !errors.Is(err, errInvalidParams) {
+			t.Errorf("error = %v, want %v", err, errInvalidParams)
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package lalamove
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// defaultBatchConcurrency is the number of OrderDetails requests BatchOrderDetails sends at once.
+const defaultBatchConcurrency = 8
+
+// Backoff applied to every worker of BatchOrderDetails after a 429 Too Many Requests response.
+const (
+	batchBackoffInitial = time.Second
+	batchBackoffMax     = 30 * time.Second
+	batchMaxRetries     = 5
+)
+
+// OrderDetailsResult is the outcome of retrieving one order in BatchOrderDetails.
+type OrderDetailsResult struct {
+	OrderID string
+	Details *OrderDetailsResponse
+	Err     error
+}
+
+// WithBatchConcurrency configures the number of requests BatchOrderDetails sends at once. Defaults to 8.
+func WithBatchConcurrency(n int) ClientOption {
+	return func(c *Client) error {
+		if n > 0 {
+			c.batchConcurrency = n
+		}
+		return nil
+	}
+}
+
+// WithOrderDetailsCache configures BatchOrderDetails to reuse the details of an order retrieved
+// less than ttl ago. Concurrent requests for the same order always share a single API call.
+func WithOrderDetailsCache(ttl time.Duration) ClientOption {
+	return func(c *Client) error {
+		c.detailsTTL = ttl
+		return nil
+	}
+}
+
+// BatchOrderDetails retrieves the details of many orders with bounded concurrency. Results are
+// aligned with ids; a failure to retrieve one order does not affect the others. When the API
+// answers 429 Too Many Requests, all workers pause with exponential backoff before retrying.
+func (c *Client) BatchOrderDetails(ctx context.Context, city CityCode, ids []string) []OrderDetailsResult {
+	results := make([]OrderDetailsResult, len(ids))
+	concurrency := c.batchConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	gate := &rateGate{now: c.now}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(ids); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				details, err := c.orderDetailsWithBackoff(ctx, city, ids[i], gate)
+				results[i] = OrderDetailsResult{OrderID: ids[i], Details: details, Err: err}
+			}
+		}()
+	}
+	for i := range ids {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func (c *Client) orderDetailsWithBackoff(ctx context.Context, city CityCode, orderID string, gate *rateGate) (*OrderDetailsResponse, error) {
+	for attempt := 0; ; attempt++ {
+		if err := gate.wait(ctx); err != nil {
+			return nil, err
+		}
+		details, err := c.sharedOrderDetails(ctx, city, orderID)
+		if !errors.Is(err, errTooManyRequests) || attempt == batchMaxRetries {
+			return details, err
+		}
+		gate.backoff(attempt)
+	}
+}
+
+// sharedOrderDetails retrieves the order through the cache and merges concurrent requests for it.
+// Every caller gets its own copy of the details, so that changing them affects neither the cache
+// nor the other callers.
+func (c *Client) sharedOrderDetails(ctx context.Context, city CityCode, orderID string) (*OrderDetailsResponse, error) {
+	key := string(city) + "/" + orderID
+	if c.detailsTTL > 0 {
+		if v, ok := c.detailsCache.get(key); ok {
+			return v.(*OrderDetailsResponse).clone(), nil
+		}
+	}
+	v, err := c.detailsFlight.do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		details, err := c.OrderDetails(ctx, city, orderID)
+		if err == nil && c.detailsTTL > 0 {
+			c.detailsCache.set(key, details, c.detailsTTL)
+		}
+		return details, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*OrderDetailsResponse).clone(), nil
+}
+
+func (r *OrderDetailsResponse) clone() *OrderDetailsResponse {
+	c := *r
+	if r.Distance != nil {
+		distance := *r.Distance
+		c.Distance = &distance
+	}
+	if r.PriceBreakdown != nil {
+		c.PriceBreakdown = r.PriceBreakdown.clone()
+	}
+	if r.Stops != nil {
+		c.Stops = make([]OrderStop, len(r.Stops))
+		for i, stop := range r.Stops {
+			c.Stops[i] = stop
+			if stop.POD != nil {
+				pod := *stop.POD
+				pod.DeliveredAt = cloneTime(stop.POD.DeliveredAt)
+				c.Stops[i].POD = &pod
+			}
+		}
+	}
+	c.CreatedAt = cloneTime(r.CreatedAt)
+	c.ScheduleAt = cloneTime(r.ScheduleAt)
+	c.DriverAssignedAt = cloneTime(r.DriverAssignedAt)
+	c.CompletedAt = cloneTime(r.CompletedAt)
+	c.Raw = append(json.RawMessage(nil), r.Raw...)
+	return &c
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+// rateGate holds back every worker of a batch until a shared backoff has elapsed.
+type rateGate struct {
+	now   func() time.Time
+	mu    sync.Mutex
+	until time.Time
+}
+
+func (g *rateGate) backoff(attempt int) {
+	d := batchBackoffInitial << uint(attempt)
+	if d > batchBackoffMax {
+		d = batchBackoffMax
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if until := g.now().Add(d); until.After(g.until) {
+		g.until = until
+	}
+}
+
+func (g *rateGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	d := g.until.Sub(g.now())
+	g.mu.Unlock()
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,275 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// detailsServer answers OrderDetails for every order except "missing", counting the requests per
+// order and the most requests in flight at once.
+type detailsServer struct {
+	mu          sync.Mutex
+	requests    map[string]int
+	inFlight    int
+	maxInFlight int
+	// delay holds every response back so that concurrent requests overlap.
+	delay time.Duration
+	// tooManyRequests is the number of 429 responses sent before the first success.
+	tooManyRequests int
+}
+
+func (s *detailsServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := path.Base(req.URL.Path)
+	s.mu.Lock()
+	if s.requests == nil {
+		s.requests = map[string]int{}
+	}
+	s.requests[id]++
+	if s.inFlight++; s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	throttled := s.tooManyRequests > 0
+	if throttled {
+		s.tooManyRequests--
+	}
+	s.mu.Unlock()
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+
+	switch {
+	case throttled:
+		return stubResponse(req, http.StatusTooManyRequests, time.Time{}, ""), nil
+	case id == "missing":
+		return stubResponse(req, http.StatusNotFound, time.Time{}, `{"message":"ERR_ORDER_NOT_FOUND"}`), nil
+	}
+	return stubResponse(req, http.StatusOK, time.Time{}, fmt.Sprintf(`{
+		"status": "ON_GOING",
+		"price": {"amount": "163.00", "currency": "PHP"},
+		"driverId": "driver-%s",
+		"priceBreakdown": {"base": "150.00", "surcharges": {"toll": "13.00"}, "total": "163.00", "currency": "PHP"},
+		"stops": [{"stopId": "1", "location": {"lat": "14.5547", "lng": "121.0244"}, "status": "PENDING"}]
+	}`, id)), nil
+}
+
+func (s *detailsServer) count(id string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[id]
+}
+
+func TestBatchOrderDetails(t *testing.T) {
+	srv := &detailsServer{delay: 10 * time.Millisecond}
+	c := testClient(t, srv, WithBatchConcurrency(3))
+	ids := []string{"1001", "1002", "missing", "1004", "1005", "1006", "1007"}
+	results := c.BatchOrderDetails(context.Background(), CityCodePhilippinesManila, ids)
+	if len(results) != len(ids) {
+		t.Fatalf("got %d results, want %d", len(results), len(ids))
+	}
+	for i, r := range results {
+		if r.OrderID != ids[i] {
+			t.Errorf("results[%d].OrderID = %s, want %s", i, r.OrderID, ids[i])
+		}
+		if ids[i] == "missing" {
+			if r.Err == nil || r.Details != nil {
+				t.Errorf("results[%d] = %+v, want an error", i, r)
+			}
+			continue
+		}
+		if r.Err != nil || r.Details.DriverID != "driver-"+ids[i] {
+			t.Errorf("results[%d] = %+v, want the details of %s", i, r, ids[i])
+		}
+	}
+	if srv.maxInFlight > 3 {
+		t.Errorf("%d requests in flight at once, want at most 3", srv.maxInFlight)
+	}
+}
+
+func TestBatchOrderDetailsBacksOff(t *testing.T) {
+	srv := &detailsServer{tooManyRequests: 1}
+	c := testClient(t, srv, WithBatchConcurrency(1))
+	start := time.Now()
+	results := c.BatchOrderDetails(context.Background(), CityCodePhilippinesManila, []string{"1001"})
+	if results[0].Err != nil {
+		t.Fatal(results[0].Err)
+	}
+	if elapsed := time.Since(start); elapsed < batchBackoffInitial {
+		t.Errorf("retried after %s, want a backoff of at least %s", elapsed, batchBackoffInitial)
+	}
+	if n := srv.count("1001"); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	srv = &detailsServer{}
+	c = testClient(t, srv)
+	if results := c.BatchOrderDetails(ctx, CityCodePhilippinesManila, []string{"1001"}); !errors.Is(results[0].Err, context.Canceled) {
+		t.Errorf("BatchOrderDetails() with a canceled context = %v, want %v", results[0].Err, context.Canceled)
+	}
+}
+
+func TestBatchOrderDetailsCache(t *testing.T) {
+	now := testNow
+	srv := &detailsServer{}
+	c := testClient(t, srv, WithOrderDetailsCache(time.Minute), WithClock(func() time.Time { return now }))
+	first := c.BatchOrderDetails(context.Background(), CityCodePhilippinesManila, []string{"1001", "1001"})
+	if n := srv.count("1001"); n != 1 {
+		t.Errorf("sent %d requests for a cached order, want 1", n)
+	}
+
+	// Changing the details handed out must not leak into the cache or the other results.
+	first[0].Details.Status = OrderStatusCanceled
+	first[0].Details.PriceBreakdown.Surcharges["toll"] = Money{Amount: "0"}
+	first[0].Details.Stops[0].Status = DeliveryStatusDelivered
+	second := c.BatchOrderDetails(context.Background(), CityCodePhilippinesManila, []string{"1001"})
+	for _, r := range []OrderDetailsResult{first[1], second[0]} {
+		if r.Details.Status != OrderStatusOngoing || r.Details.PriceBreakdown.Surcharges["toll"].Amount != "13.00" || r.Details.Stops[0].Status != DeliveryStatusPending {
+			t.Errorf("details = %+v, want the details as received", r.Details)
+		}
+	}
+	if n := srv.count("1001"); n != 1 {
+		t.Errorf("sent %d requests within the TTL, want 1", n)
+	}
+
+	now = now.Add(time.Minute)
+	c.BatchOrderDetails(context.Background(), CityCodePhilippinesManila, []string{"1001"})
+	if n := srv.count("1001"); n != 2 {
+		t.Errorf("sent %d requests after the TTL, want 2", n)
+	}
+}
+
+// TestBatchOrderDetailsConcurrentCallers hands the same cached order to many batches at once, each
+// of which changes its copy. Run with -race to check the details are not shared.
+func TestBatchOrderDetailsConcurrentCallers(t *testing.T) {
+	srv := &detailsServer{delay: 5 * time.Millisecond}
+	c := testClient(t, srv, WithOrderDetailsCache(time.Hour))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for _, r := range c.BatchOrderDetails(context.Background(), CityCodePhilippinesManila, []string{"1001", "1002"}) {
+				if r.Err != nil {
+					t.Error(r.Err)
+					continue
+				}
+				if r.Details.Status != OrderStatusOngoing {
+					t.Errorf("status = %s, want %s", r.Details.Status, OrderStatusOngoing)
+				}
+				r.Details.Status = OrderStatus(fmt.Sprint("CHANGED_", i))
+				r.Details.PriceBreakdown.Surcharges["toll"] = Money{Amount: fmt.Sprint(i)}
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, id := range []string{"1001", "1002"} {
+		if n := srv.count(id); n != 1 {
+			t.Errorf("sent %d requests for %s, want 1", n, id)
+		}
+	}
+}
+
+func TestFlightGroup(t *testing.T) {
+	var g flightGroup
+	var calls int32
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "details", nil
+	}
+
+	var wg sync.WaitGroup
+	values := make([]interface{}, 5)
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := g.do(context.Background(), "PH_MNL/1001", fn)
+			if err != nil {
+				t.Error(err)
+			}
+			values[i] = v
+		}(i)
+	}
+	// Let every caller join the call in flight before it completes.
+	for !inFlight(&g, "PH_MNL/1001") {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("made %d calls, want 1", calls)
+	}
+	for i, v := range values {
+		if v != "details" {
+			t.Errorf("caller %d got %v, want the shared result", i, v)
+		}
+	}
+
+	if _, err := g.do(context.Background(), "PH_MNL/1001", func(ctx context.Context) (interface{}, error) {
+		return nil, errUnknownError
+	}); !errors.Is(err, errUnknownError) {
+		t.Errorf("do() = %v, want %v", err, errUnknownError)
+	}
+	if inFlight(&g, "PH_MNL/1001") {
+		t.Error("a completed call is still in flight")
+	}
+}
+
+func TestFlightGroupCanceledLeader(t *testing.T) {
+	var g flightGroup
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	leaderDone := make(chan error)
+	go func() {
+		_, err := g.do(leaderCtx, "key", func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		leaderDone <- err
+	}()
+	<-started
+
+	waiterDone := make(chan interface{})
+	go func() {
+		v, err := g.do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+			return "retried", nil
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		waiterDone <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	canceledCtx, cancelWaiter := context.WithCancel(context.Background())
+	cancelWaiter()
+	if _, err := g.do(canceledCtx, "key", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("do() of a canceled waiter = %v, want %v", err, context.Canceled)
+	}
+
+	cancelLeader()
+	if err := <-leaderDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("do() of the canceled leader = %v, want %v", err, context.Canceled)
+	}
+	if v := <-waiterDone; v != "retried" {
+		t.Errorf("waiter got %v, want the result of its own call", v)
+	}
+}
+
+func inFlight(g *flightGroup, key string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.calls[key] != nil
+}
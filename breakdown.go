@@ -0,0 +1,209 @@
+package lalamove
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PriceBreakdown itemizes the total fee of a quotation or an order. All amounts share the currency
+// of Total.
+type PriceBreakdown struct {
+	// Base is the base fare of the service type.
+	Base Money
+	// ExtraMileage is charged for the distance beyond the one covered by the base fare.
+	ExtraMileage Money
+	// SpecialRequests are the surcharges of each special request.
+	SpecialRequests map[SpecialRequest]Money
+	// PriorityFee is the tip added to the order.
+	PriorityFee Money
+	// Surcharge is the sum of the surcharges not itemized in Surcharges.
+	Surcharge Money
+	// Surcharges are other surcharges by name, e.g. tolls or peak hours.
+	Surcharges map[string]Money
+	// Discount is subtracted from the sum of the other items. It is always a positive amount; a
+	// discount the API reports as negative is decoded as its absolute value.
+	Discount Money
+	// Total is the total fee.
+	Total Money
+}
+
+func (b *PriceBreakdown) clone() *PriceBreakdown {
+	c := *b
+	if b.SpecialRequests != nil {
+		c.SpecialRequests = make(map[SpecialRequest]Money, len(b.SpecialRequests))
+		for k, v := range b.SpecialRequests {
+			c.SpecialRequests[k] = v
+		}
+	}
+	if b.Surcharges != nil {
+		c.Surcharges = make(map[string]Money, len(b.Surcharges))
+		for k, v := range b.Surcharges {
+			c.Surcharges[k] = v
+		}
+	}
+	return &c
+}
+
+// priceBreakdownJSON is the wire format of PriceBreakdown.
+type priceBreakdownJSON struct {
+	Base            string                    `json:"base"`
+	ExtraMileage    string                    `json:"extraMileage,omitempty"`
+	SpecialRequests map[SpecialRequest]string `json:"specialRequests,omitempty"`
+	PriorityFee     string                    `json:"priorityFee,omitempty"`
+	Surcharge       string                    `json:"surcharge,omitempty"`
+	Surcharges      map[string]string         `json:"surcharges,omitempty"`
+	Discount        string                    `json:"discount,omitempty"`
+	Total           string                    `json:"total"`
+	Currency        string                    `json:"currency"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *PriceBreakdown) UnmarshalJSON(data []byte) error {
+	w := priceBreakdownJSON{}
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	money := func(amount string) Money {
+		if amount == "" {
+			return Money{}
+		}
+		return Money{Amount: amount, Currency: w.Currency}
+	}
+	*b = PriceBreakdown{
+		Base:         money(w.Base),
+		ExtraMileage: money(w.ExtraMileage),
+		PriorityFee:  money(w.PriorityFee),
+		Surcharge:    money(w.Surcharge),
+		Discount:     money(strings.TrimPrefix(w.Discount, "-")),
+		Total:        money(w.Total),
+	}
+	if len(w.SpecialRequests) > 0 {
+		b.SpecialRequests = make(map[SpecialRequest]Money, len(w.SpecialRequests))
+		for k, v := range w.SpecialRequests {
+			b.SpecialRequests[k] = money(v)
+		}
+	}
+	if len(w.Surcharges) > 0 {
+		b.Surcharges = make(map[string]Money, len(w.Surcharges))
+		for k, v := range w.Surcharges {
+			b.Surcharges[k] = money(v)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b PriceBreakdown) MarshalJSON() ([]byte, error) {
+	w := priceBreakdownJSON{
+		Base:         b.Base.Amount,
+		ExtraMileage: b.ExtraMileage.Amount,
+		PriorityFee:  b.PriorityFee.Amount,
+		Surcharge:    b.Surcharge.Amount,
+		Discount:     b.Discount.Amount,
+		Total:        b.Total.Amount,
+		Currency:     b.Total.Currency,
+	}
+	if len(b.SpecialRequests) > 0 {
+		w.SpecialRequests = make(map[SpecialRequest]string, len(b.SpecialRequests))
+		for k, v := range b.SpecialRequests {
+			w.SpecialRequests[k] = v.Amount
+		}
+	}
+	if len(b.Surcharges) > 0 {
+		w.Surcharges = make(map[string]string, len(b.Surcharges))
+		for k, v := range b.Surcharges {
+			w.Surcharges[k] = v.Amount
+		}
+	}
+	return json.Marshal(w)
+}
+
+// Sum adds up the items of the breakdown, minus the discount.
+func (b PriceBreakdown) Sum() (Money, error) {
+	items := []Money{b.Base, b.ExtraMileage, b.PriorityFee, b.Surcharge}
+	for _, k := range sortedSpecialRequests(b.SpecialRequests) {
+		items = append(items, b.SpecialRequests[k])
+	}
+	for _, k := range sortedKeys(b.Surcharges) {
+		items = append(items, b.Surcharges[k])
+	}
+	sum, err := sumPrices(nonZero(items, b.Total.Currency))
+	if err != nil {
+		return Money{}, err
+	}
+	if b.Discount.Amount != "" {
+		return sum.Sub(b.Discount)
+	}
+	return sum, nil
+}
+
+// Verify checks that the items of the breakdown add up to its total.
+func (b PriceBreakdown) Verify() error {
+	sum, err := b.Sum()
+	if err != nil {
+		return err
+	}
+	if cmp, err := sum.Cmp(b.Total); err != nil {
+		return err
+	} else if cmp != 0 {
+		return fmt.Errorf("breakdown sums up to %s %s, total is %s %s: %w",
+			sum.Amount, sum.Currency, b.Total.Amount, b.Total.Currency, errPriceMismatch)
+	}
+	return nil
+}
+
+// VerifyBreakdown checks that the price breakdown, if any, adds up to the total fee.
+func (r *GetQuotationResponse) VerifyBreakdown() error {
+	if r.PriceBreakdown == nil {
+		return nil
+	}
+	if err := r.PriceBreakdown.Verify(); err != nil {
+		return err
+	}
+	total := Money{Amount: r.Amount, Currency: r.Currency}
+	if cmp, err := r.PriceBreakdown.Total.Cmp(total); err != nil {
+		return err
+	} else if cmp != 0 {
+		return fmt.Errorf("breakdown total is %s %s, total fee is %s %s: %w",
+			r.PriceBreakdown.Total.Amount, r.PriceBreakdown.Total.Currency, r.Amount, r.Currency, errPriceMismatch)
+	}
+	return nil
+}
+
+// nonZero drops the empty items and gives the others the currency of the total if they lack one.
+func nonZero(items []Money, currency string) []Money {
+	var kept []Money
+	for _, m := range items {
+		if m.Amount == "" {
+			continue
+		}
+		if m.Currency == "" {
+			m.Currency = currency
+		}
+		kept = append(kept, m)
+	}
+	if len(kept) == 0 {
+		kept = append(kept, Money{Amount: "0", Currency: currency})
+	}
+	return kept
+}
+
+func sortedSpecialRequests(m map[SpecialRequest]Money) []SpecialRequest {
+	keys := make([]SpecialRequest, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+func sortedKeys(m map[string]Money) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
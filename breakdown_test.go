@@ -0,0 +1,116 @@
+package lalamove
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestPriceBreakdownVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		wire    string
+		wantErr error
+	}{
+		{
+			name: "base only",
+			wire: `{"base":"90","total":"90","currency":"PHP"}`,
+		},
+		{
+			name: "every item",
+			wire: `{"base":"90","extraMileage":"25.50","specialRequests":{"PURCHASE_SERVICE":"40"},"priorityFee":"20",` +
+				`"surcharge":"5","surcharges":{"toll":"12.25"},"discount":"10","total":"182.75","currency":"PHP"}`,
+		},
+		{
+			name: "negative discount is subtracted",
+			wire: `{"base":"90","discount":"-10","total":"80","currency":"PHP"}`,
+		},
+		{
+			name: "explicitly positive amounts",
+			wire: `{"base":"+90","discount":"+10","total":"80","currency":"PHP"}`,
+		},
+		{
+			name:    "total mismatch",
+			wire:    `{"base":"90","priorityFee":"20","total":"100","currency":"PHP"}`,
+			wantErr: errPriceMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b PriceBreakdown
+			if err := json.Unmarshal([]byte(tt.wire), &b); err != nil {
+				t.Fatal(err)
+			}
+			err := b.Verify()
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("Verify() = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPriceBreakdownVerifyInvalidAmount(t *testing.T) {
+	b := PriceBreakdown{Base: Money{Amount: "ninety", Currency: "PHP"}, Total: Money{Amount: "90", Currency: "PHP"}}
+	if err := b.Verify(); err == nil {
+		t.Error("Verify() = nil, want an error")
+	}
+}
+
+func TestPriceSub(t *testing.T) {
+	tests := []struct {
+		p, q, want string
+	}{
+		{"100", "20", "80"},
+		{"100", "+20", "80"},
+		{"100", "-20", "120"},
+		{"100.50", "0.5", "100.00"},
+		{"100", "", "100"},
+	}
+	for _, tt := range tests {
+		got, err := Money{Amount: tt.p, Currency: "PHP"}.Sub(Money{Amount: tt.q, Currency: "PHP"})
+		if err != nil {
+			t.Errorf("%s - %s: %v", tt.p, tt.q, err)
+			continue
+		}
+		if got.Amount != tt.want {
+			t.Errorf("%s - %s = %s, want %s", tt.p, tt.q, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestPriceBreakdownRoundTrip(t *testing.T) {
+	wire := `{"base":"150.00","extraMileage":"23.00","specialRequests":{"LALABAG":"10.00"},"priorityFee":"20.00","surcharges":{"toll":"5.00"},"discount":"25.00","total":"183.00","currency":"PHP"}`
+	var b PriceBreakdown
+	if err := json.Unmarshal([]byte(wire), &b); err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != wire {
+		t.Errorf("Marshal() = %s, want %s", got, wire)
+	}
+}
+
+func TestVerifyBreakdown(t *testing.T) {
+	breakdown := &PriceBreakdown{Base: Money{Amount: "150.00", Currency: "PHP"}, Total: Money{Amount: "150.00", Currency: "PHP"}}
+	tests := []struct {
+		name string
+		resp GetQuotationResponse
+		want error
+	}{
+		{"without breakdown", GetQuotationResponse{Amount: "150.00", Currency: "PHP"}, nil},
+		{"matching total fee", GetQuotationResponse{Amount: "150", Currency: "PHP", PriceBreakdown: breakdown}, nil},
+		{"other total fee", GetQuotationResponse{Amount: "163.00", Currency: "PHP", PriceBreakdown: breakdown}, errPriceMismatch},
+		{"other currency", GetQuotationResponse{Amount: "150.00", Currency: "SGD", PriceBreakdown: breakdown}, errInvalidCurrency},
+	}
+	for _, tt := range tests {
+		if err := tt.resp.VerifyBreakdown(); !errors.Is(err, tt.want) || (err == nil) != (tt.want == nil) {
+			t.Errorf("VerifyBreakdown(%s) = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+// CircuitState enum
+const (
+	// CircuitClosed - Requests flow normally and failures are counted.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen - Requests fail fast with ErrCircuitOpen until the open timeout elapses.
+	CircuitOpen
+	// CircuitHalfOpen - A limited number of probe requests are let through to test recovery.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitKey identifies a circuit. Each endpoint has its own circuit per market.
+type CircuitKey struct {
+	// Endpoint is the method and path template, e.g. "GET /v2/orders/{id}".
+	Endpoint string
+	// Country is the market the request was sent to.
+	Country LLMCountry
+}
+
+// CircuitBreakerConfig configures the circuit breaker of a Lalamove API client.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures which opens a circuit. Defaults to 5.
+	FailureThreshold int
+	// OpenTimeout is how long a circuit stays open before probing. Defaults to 30 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of successful probes needed to close a half-open circuit.
+	// It is also the number of probes allowed in flight at once. Defaults to 1.
+	HalfOpenProbes int
+	// OnStateChange, if set, is called whenever a circuit changes state.
+	OnStateChange func(key CircuitKey, from, to CircuitState)
+}
+
+// ErrCircuitOpen is matched by errors.Is for every CircuitOpenError.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitOpenError is returned instead of sending a request while its circuit is open.
+type CircuitOpenError struct {
+	Key CircuitKey
+	// RetryAfter is the time left until the circuit starts probing.
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s for %s in %s, retry after %s", ErrCircuitOpen, e.Key.Endpoint, e.Key.Country, e.RetryAfter)
+}
+
+// Is reports whether target is ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// WithCircuitBreaker configures a Lalamove API client to stop sending requests to an endpoint of
+// a market after repeated failures. Network errors, timeouts, rate limiting and 5xx responses count
+// as failures; other 4xx responses, such as validation errors or unknown orders, do not.
+func WithCircuitBreaker(cfg CircuitBreakerConfig) ClientOption {
+	return func(c *Client) error {
+		if cfg.FailureThreshold <= 0 {
+			cfg.FailureThreshold = 5
+		}
+		if cfg.OpenTimeout <= 0 {
+			cfg.OpenTimeout = 30 * time.Second
+		}
+		if cfg.HalfOpenProbes <= 0 {
+			cfg.HalfOpenProbes = 1
+		}
+		c.breaker = &circuitBreaker{cfg: cfg, now: func() time.Time { return c.now() }, circuits: map[CircuitKey]*circuit{}}
+		return nil
+	}
+}
+
+type circuit struct {
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	probes    int
+	successes int
+	// generation is incremented on every transition, so that results of requests admitted in an
+	// earlier state can be told apart.
+	generation uint64
+}
+
+// circuitTicket is handed out by allow to a request admitted on a circuit and given back to record
+// with the result of the request.
+type circuitTicket struct {
+	generation uint64
+	// probe is set for requests admitted while the circuit was half-open.
+	probe bool
+}
+
+type circuitBreaker struct {
+	cfg      CircuitBreakerConfig
+	now      func() time.Time
+	mu       sync.Mutex
+	circuits map[CircuitKey]*circuit
+}
+
+type stateChange struct {
+	key      CircuitKey
+	from, to CircuitState
+}
+
+// allow reports whether a request may be sent on the circuit, and if so returns its ticket.
+func (b *circuitBreaker) allow(key CircuitKey) (circuitTicket, error) {
+	b.mu.Lock()
+	cb := b.circuit(key)
+	var changes []stateChange
+	var err error
+	if cb.state == CircuitOpen {
+		if elapsed := b.now().Sub(cb.openedAt); elapsed < b.cfg.OpenTimeout {
+			err = &CircuitOpenError{Key: key, RetryAfter: b.cfg.OpenTimeout - elapsed}
+		} else {
+			changes = append(changes, b.transition(key, cb, CircuitHalfOpen))
+		}
+	}
+	ticket := circuitTicket{generation: cb.generation}
+	if err == nil && cb.state == CircuitHalfOpen {
+		if cb.probes >= b.cfg.HalfOpenProbes {
+			err = &CircuitOpenError{Key: key}
+		} else {
+			cb.probes++
+			ticket.probe = true
+		}
+	}
+	b.mu.Unlock()
+	b.notify(changes)
+	return ticket, err
+}
+
+// record reports the outcome of a request admitted with the ticket. Results of requests admitted
+// before the circuit last changed state are ignored, and requests canceled by the caller only
+// release their probe slot.
+func (b *circuitBreaker) record(key CircuitKey, ticket circuitTicket, err error) {
+	failed := isCircuitFailure(err)
+	b.mu.Lock()
+	cb := b.circuit(key)
+	var changes []stateChange
+	switch {
+	case ticket.generation != cb.generation:
+	case errors.Is(err, context.Canceled):
+		if ticket.probe {
+			cb.probes--
+		}
+	case cb.state == CircuitClosed:
+		if !failed {
+			cb.failures = 0
+		} else if cb.failures++; cb.failures >= b.cfg.FailureThreshold {
+			changes = append(changes, b.transition(key, cb, CircuitOpen))
+		}
+	case cb.state == CircuitHalfOpen:
+		cb.probes--
+		if failed {
+			changes = append(changes, b.transition(key, cb, CircuitOpen))
+		} else if cb.successes++; cb.successes >= b.cfg.HalfOpenProbes {
+			changes = append(changes, b.transition(key, cb, CircuitClosed))
+		}
+	}
+	b.mu.Unlock()
+	b.notify(changes)
+}
+
+func (b *circuitBreaker) circuit(key CircuitKey) *circuit {
+	cb, ok := b.circuits[key]
+	if !ok {
+		cb = &circuit{}
+		b.circuits[key] = cb
+	}
+	return cb
+}
+
+func (b *circuitBreaker) transition(key CircuitKey, cb *circuit, to CircuitState) stateChange {
+	change := stateChange{key: key, from: cb.state, to: to}
+	cb.state = to
+	cb.generation++
+	cb.failures, cb.probes, cb.successes = 0, 0, 0
+	if to == CircuitOpen {
+		cb.openedAt = b.now()
+	}
+	return change
+}
+
+func (b *circuitBreaker) notify(changes []stateChange) {
+	if b.cfg.OnStateChange == nil {
+		return
+	}
+	for _, change := range changes {
+		b.cfg.OnStateChange(change.key, change.from, change.to)
+	}
+}
+
+// circuitKey derives the circuit of a request, replacing order and driver IDs in the path.
+func circuitKey(city CityCode, method, path string) CircuitKey {
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		if segments[i-1] == "orders" || segments[i-1] == "drivers" {
+			segments[i] = "{id}"
+		}
+	}
+	return CircuitKey{
+		Endpoint: method + " " + strings.Join(segments, "/"),
+		Country:  city.GetLLMCountry(),
+	}
+}
+
+// isCircuitFailure reports whether err indicates the endpoint is unhealthy rather than the request being invalid:
+// a network error, a timeout, rate limiting or a 5xx response.
+func isCircuitFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusTooManyRequests
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,263 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestBreaker constructs a circuit breaker reading the time from now.
+func newTestBreaker(cfg CircuitBreakerConfig, now *time.Time) *circuitBreaker {
+	return &circuitBreaker{cfg: cfg, now: func() time.Time { return *now }, circuits: map[CircuitKey]*circuit{}}
+}
+
+func TestIsCircuitFailure(t *testing.T) {
+	httpErr := func(status int, err error) error { return &HTTPError{StatusCode: status, Err: err} }
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{context.Canceled, false},
+		{errInvalidParams, false},
+		{httpErr(http.StatusBadRequest, errUnknownError), false},
+		{httpErr(http.StatusUnauthorized, errUnauthorized), false},
+		{httpErr(http.StatusForbidden, errUnknownError), false},
+		{httpErr(http.StatusNotFound, errUnknownError), false},
+		{httpErr(http.StatusConflict, errInvalidParams), false},
+		{httpErr(http.StatusTooManyRequests, errTooManyRequests), true},
+		{httpErr(http.StatusInternalServerError, errUnknownError), true},
+		{httpErr(http.StatusServiceUnavailable, errUnknownError), true},
+		{&url.Error{Op: "Get", URL: "https://rest.lalamove.com", Err: errors.New("connection refused")}, true},
+		{context.DeadlineExceeded, true},
+		{fmt.Errorf("order 1001: %w", context.DeadlineExceeded), true},
+	}
+	for _, tt := range tests {
+		if got := isCircuitFailure(tt.err); got != tt.want {
+			t.Errorf("isCircuitFailure(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	now := testNow
+	var changes []string
+	b := newTestBreaker(CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenProbes:   2,
+		OnStateChange: func(key CircuitKey, from, to CircuitState) {
+			changes = append(changes, fmt.Sprintf("%s->%s", from, to))
+		},
+	}, &now)
+	key := circuitKey(CityCodePhilippinesManila, "GET", "/v2/orders/1001")
+	send := func(err error) error {
+		ticket, allowErr := b.allow(key)
+		if allowErr != nil {
+			return allowErr
+		}
+		b.record(key, ticket, err)
+		return nil
+	}
+	state := func() CircuitState { return b.circuits[key].state }
+	unavailable := &HTTPError{StatusCode: http.StatusServiceUnavailable, Err: errUnknownError}
+
+	// Client errors, successes and cancellations do not count towards the threshold.
+	notFound := &HTTPError{StatusCode: http.StatusNotFound, Err: errUnknownError}
+	for _, err := range []error{unavailable, notFound, nil, errInvalidParams, unavailable, context.Canceled} {
+		if err := send(err); err != nil {
+			t.Fatalf("closed circuit refused a request: %v", err)
+		}
+	}
+	if state() != CircuitClosed {
+		t.Fatalf("state = %s, want closed", state())
+	}
+	send(&HTTPError{StatusCode: http.StatusTooManyRequests, Err: errTooManyRequests})
+	if state() != CircuitOpen {
+		t.Fatalf("state after %d consecutive failures = %s, want open", b.cfg.FailureThreshold, state())
+	}
+
+	now = now.Add(10 * time.Second)
+	_, err := b.allow(key)
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || !errors.Is(err, ErrCircuitOpen) || openErr.RetryAfter != 20*time.Second {
+		t.Fatalf("allow on an open circuit = %v, want a CircuitOpenError retrying after 20s", err)
+	}
+
+	// Once the timeout elapsed, probes are let through up to HalfOpenProbes at once.
+	now = now.Add(20 * time.Second)
+	var probes []circuitTicket
+	for i := 0; i < 2; i++ {
+		ticket, err := b.allow(key)
+		if err != nil {
+			t.Fatalf("probe %d refused: %v", i, err)
+		}
+		probes = append(probes, ticket)
+	}
+	if state() != CircuitHalfOpen {
+		t.Fatalf("state = %s, want half-open", state())
+	}
+	if _, err := b.allow(key); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("third concurrent probe = %v, want ErrCircuitOpen", err)
+	}
+	b.record(key, probes[0], nil)
+	b.record(key, probes[1], unavailable)
+	if state() != CircuitOpen {
+		t.Fatalf("state after a failed probe = %s, want open", state())
+	}
+
+	now = now.Add(30 * time.Second)
+	for i := 0; i < 2; i++ {
+		if err := send(nil); err != nil {
+			t.Fatalf("probe %d refused: %v", i, err)
+		}
+	}
+	if state() != CircuitClosed {
+		t.Fatalf("state after successful probes = %s, want closed", state())
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("state changes = %v, want %v", changes, want)
+	}
+}
+
+func TestCircuitBreakerIgnoresStaleResults(t *testing.T) {
+	now := testNow
+	b := newTestBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second, HalfOpenProbes: 1}, &now)
+	key := circuitKey(CityCodePhilippinesManila, "GET", "/v2/orders/1001")
+	unavailable := &HTTPError{StatusCode: http.StatusServiceUnavailable, Err: errUnknownError}
+
+	// A request admitted while closed fails only after the circuit was opened by another one.
+	slow, _ := b.allow(key)
+	fast, _ := b.allow(key)
+	b.record(key, fast, unavailable)
+	now = now.Add(time.Second)
+	probe, err := b.allow(key)
+	if err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	b.record(key, slow, unavailable)
+	if state := b.circuits[key].state; state != CircuitHalfOpen {
+		t.Fatalf("state after a stale failure = %s, want half-open", state)
+	}
+	b.record(key, probe, nil)
+	if state := b.circuits[key].state; state != CircuitClosed {
+		t.Fatalf("state after the probe succeeded = %s, want closed", state)
+	}
+
+	// A success admitted before the circuit last closed does not reset the failures counted since.
+	b.cfg.FailureThreshold = 2
+	old, _ := b.allow(key)
+	for i := 0; i < 2; i++ {
+		ticket, _ := b.allow(key)
+		b.record(key, ticket, unavailable)
+	}
+	now = now.Add(time.Second)
+	probe, _ = b.allow(key)
+	b.record(key, probe, nil)
+	ticket, _ := b.allow(key)
+	b.record(key, ticket, unavailable)
+	b.record(key, old, nil)
+	ticket, _ = b.allow(key)
+	b.record(key, ticket, unavailable)
+	if state := b.circuits[key].state; state != CircuitOpen {
+		t.Errorf("state after two failures and a stale success = %s, want open", state)
+	}
+}
+
+func TestCircuitBreakerReleasesCanceledProbes(t *testing.T) {
+	now := testNow
+	b := newTestBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second, HalfOpenProbes: 1}, &now)
+	key := circuitKey(CityCodeSingaporeSingapore, "GET", "/v2/orders/1001")
+	b.circuits[key] = &circuit{state: CircuitHalfOpen}
+	for i := 0; i < 2; i++ {
+		ticket, err := b.allow(key)
+		if err != nil {
+			t.Fatalf("probe %d refused: %v", i, err)
+		}
+		b.record(key, ticket, context.Canceled)
+	}
+	if state := b.circuits[key].state; state != CircuitHalfOpen {
+		t.Errorf("state after canceled probes = %s, want half-open", state)
+	}
+}
+
+func TestCircuitKey(t *testing.T) {
+	tests := []struct {
+		city   CityCode
+		method string
+		path   string
+		want   CircuitKey
+	}{
+		{CityCodePhilippinesManila, "POST", "/v2/quotations", CircuitKey{"POST /v2/quotations", "PH_MNL"}},
+		{CityCodePhilippinesCebu, "GET", "/v2/orders/1001", CircuitKey{"GET /v2/orders/{id}", "PH_CEB"}},
+		{CityCodeSingaporeSingapore, "GET", "/v2/orders/1001/drivers/21712/location", CircuitKey{"GET /v2/orders/{id}/drivers/{id}/location", "SG"}},
+		{CityCodeHongKongHongKong, "PUT", "/v2/webhook", CircuitKey{"PUT /v2/webhook", "HK"}},
+	}
+	for _, tt := range tests {
+		if got := circuitKey(tt.city, tt.method, tt.path); got != tt.want {
+			t.Errorf("circuitKey(%s, %s %s) = %+v, want %+v", tt.city, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	now := testNow
+	// status is the status answered to requests to each market.
+	status := map[string]int{"PH_MNL": http.StatusServiceUnavailable, "PH_CEB": http.StatusOK}
+	sent := 0
+	c := testClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		code := status[req.Header.Get("X-LLM-Country")]
+		if code != http.StatusOK {
+			return stubResponse(req, code, time.Time{}, ""), nil
+		}
+		return stubResponse(req, code, time.Time{}, `{"status":"ON_GOING","price":{"amount":"108.00","currency":"PHP"}}`), nil
+	}),
+		WithClock(func() time.Time { return now }),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute}),
+	)
+	ctx := context.Background()
+
+	// Orders which do not exist do not open the circuit.
+	status["PH_MNL"] = http.StatusNotFound
+	for i := 0; i < 3; i++ {
+		var httpErr *HTTPError
+		if _, err := c.OrderDetails(ctx, CityCodePhilippinesManila, "0"); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+			t.Fatalf("request %d error = %v, want a 404 HTTPError", i, err)
+		}
+	}
+
+	status["PH_MNL"] = http.StatusServiceUnavailable
+	for i := 0; i < 2; i++ {
+		if _, err := c.OrderDetails(ctx, CityCodePhilippinesManila, "1001"); !errors.Is(err, errUnknownError) {
+			t.Fatalf("request %d error = %v, want %v", i, err, errUnknownError)
+		}
+	}
+	before := sent
+	if _, err := c.OrderDetails(ctx, CityCodePhilippinesManila, "1001"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("error = %v, want ErrCircuitOpen", err)
+	}
+	if sent != before {
+		t.Error("request sent while the circuit was open")
+	}
+	if _, err := c.OrderDetails(ctx, CityCodePhilippinesCebu, "1001"); err != nil {
+		t.Fatalf("error in another market = %v, want the request to be sent", err)
+	}
+
+	status["PH_MNL"] = http.StatusOK
+	now = now.Add(time.Minute)
+	details, err := c.OrderDetails(ctx, CityCodePhilippinesManila, "1001")
+	if err != nil {
+		t.Fatalf("probe error = %v", err)
+	}
+	if details.Status != OrderStatusOngoing {
+		t.Errorf("status = %s, want %s", details.Status, OrderStatusOngoing)
+	}
+}
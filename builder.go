@@ -0,0 +1,151 @@
+package lalamove
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuoteBuilder builds a GetQuotationRequest for a city step by step.
+//
+//	req, err := lalamove.NewQuote(lalamove.CityCodePhilippinesManila).
+//		Service(lalamove.ServiceTypeVan).
+//		Pickup(pickup, "Ayala Avenue, Makati", sender).
+//		DropOff(dropOff, "Bonifacio High Street, Taguig", recipient, "Unit 12B").
+//		With(lalamove.SpecialRequestCOD).
+//		Build()
+type QuoteBuilder struct {
+	city            CityCode
+	locale          Locale
+	serviceType     ServiceType
+	pickup          *quoteStop
+	requester       Contact
+	drops           []quoteStop
+	deliveries      []DeliveryInfo
+	scheduleAt      *time.Time
+	specialRequests []SpecialRequest
+	now             func() time.Time
+}
+
+type quoteStop struct {
+	location Location
+	address  string
+}
+
+// NewQuote starts a quotation request in the city. Addresses use the first locale of the country
+// of the city unless another one is set with Locale.
+func NewQuote(city CityCode) *QuoteBuilder {
+	b := &QuoteBuilder{city: city, now: time.Now}
+	if locales := city.GetCountry().Locales; len(locales) > 0 {
+		b.locale = locales[0]
+	}
+	return b
+}
+
+// NewQuote starts a quotation request in the city whose schedule is validated against the clock of
+// the client.
+func (c *Client) NewQuote(city CityCode) *QuoteBuilder {
+	return NewQuote(city).Clock(c.now)
+}
+
+// Clock sets the source of the current time the schedule is validated against.
+func (b *QuoteBuilder) Clock(now func() time.Time) *QuoteBuilder {
+	b.now = now
+	return b
+}
+
+// Locale sets the locale of the addresses.
+func (b *QuoteBuilder) Locale(locale Locale) *QuoteBuilder {
+	b.locale = locale
+	return b
+}
+
+// Service sets the type of vehicle.
+func (b *QuoteBuilder) Service(serviceType ServiceType) *QuoteBuilder {
+	b.serviceType = serviceType
+	return b
+}
+
+// Pickup sets the pick up point and the requester contact.
+func (b *QuoteBuilder) Pickup(loc Location, address string, contact Contact) *QuoteBuilder {
+	b.pickup = &quoteStop{location: loc, address: address}
+	b.requester = contact
+	return b
+}
+
+// DropOff adds a drop off point and its recipient. Remarks may be empty.
+func (b *QuoteBuilder) DropOff(loc Location, address string, contact Contact, remarks string) *QuoteBuilder {
+	b.drops = append(b.drops, quoteStop{location: loc, address: address})
+	d := DeliveryInfo{Contact: contact}
+	if remarks != "" {
+		d.Remarks = &remarks
+	}
+	b.deliveries = append(b.deliveries, d)
+	return b
+}
+
+// Schedule sets the pick up time. Requests without a schedule are immediate orders.
+func (b *QuoteBuilder) Schedule(t time.Time) *QuoteBuilder {
+	b.scheduleAt = &t
+	return b
+}
+
+// With adds special requests.
+func (b *QuoteBuilder) With(specialRequests ...SpecialRequest) *QuoteBuilder {
+	b.specialRequests = append(b.specialRequests, specialRequests...)
+	return b
+}
+
+// Build assembles and validates the request.
+func (b *QuoteBuilder) Build() (*GetQuotationRequest, error) {
+	if b.city.GetCountry().Code == "" {
+		return nil, errInvalidCountry
+	}
+	if !b.localeSupported() {
+		return nil, fmt.Errorf("%s: %w", b.locale, errInvalidLocale)
+	}
+	if b.pickup == nil {
+		return nil, fmt.Errorf("pickup: %w", errRequiredField)
+	}
+	req := &GetQuotationRequest{
+		ServiceType:      b.serviceType,
+		Stops:            []Waypoint{b.waypoint(*b.pickup)},
+		RequesterContact: b.requester,
+	}
+	for _, drop := range b.drops {
+		req.Stops = append(req.Stops, b.waypoint(drop))
+	}
+	for i, d := range b.deliveries {
+		d.ToStop = int64(i + 1)
+		req.Deliveries = append(req.Deliveries, d)
+	}
+	if b.scheduleAt != nil {
+		scheduleAt := b.scheduleAt.UTC().Format(time.RFC3339)
+		req.ScheduleAt = &scheduleAt
+	}
+	if len(b.specialRequests) > 0 {
+		specialRequests := append([]SpecialRequest(nil), b.specialRequests...)
+		req.SpecialRequests = &specialRequests
+	}
+	if err := req.ValidateAt(b.now()); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (b *QuoteBuilder) waypoint(stop quoteStop) Waypoint {
+	return Waypoint{
+		Location: stop.location,
+		Addresses: AddressTranslations{
+			b.locale: {DisplayString: stop.address, Country: b.city.GetLLMCountry()},
+		},
+	}
+}
+
+func (b *QuoteBuilder) localeSupported() bool {
+	for _, locale := range b.city.GetCountry().Locales {
+		if locale == b.locale {
+			return true
+		}
+	}
+	return false
+}
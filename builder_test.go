@@ -0,0 +1,112 @@
+package lalamove
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQuoteBuilder(t *testing.T) {
+	want := testQuotation()
+	remarks := "Unit 12B"
+	want.Deliveries[0].Remarks = &remarks
+	scheduleAt := "2026-01-01T10:00:00Z"
+	want.ScheduleAt = &scheduleAt
+	want.SpecialRequests = &[]SpecialRequest{SpecialRequestCOD}
+
+	got, err := NewQuote(CityCodePhilippinesManila).
+		Clock(func() time.Time { return testNow }).
+		Service(ServiceTypeMotorcycle).
+		Pickup(want.Stops[0].Location, "Ayala Avenue, Makati City, Metro Manila", want.RequesterContact).
+		DropOff(want.Stops[1].Location, "Ortigas Avenue, Pasig City, Metro Manila", want.Deliveries[0].Contact, remarks).
+		Schedule(testNow.Add(time.Hour).In(time.FixedZone("PHT", 8*60*60))).
+		With(SpecialRequestCOD).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() = %+v, want %+v", got, want)
+	}
+}
+
+func TestQuoteBuilderDeliveries(t *testing.T) {
+	contact := Contact{Name: "Maria Santos", Phone: "+639179876543"}
+	got, err := NewQuote(CityCodeHongKongHongKong).
+		Locale(LocaleHongKongZH).
+		Service(ServiceTypeVan).
+		Pickup(Location{Lat: "22.3193", Lng: "114.1694"}, "旺角", contact).
+		DropOff(Location{Lat: "22.2783", Lng: "114.1747"}, "灣仔", contact, "").
+		DropOff(Location{Lat: "22.2849", Lng: "114.1580"}, "中環", contact, "").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, d := range got.Deliveries {
+		if d.ToStop != int64(i+1) || d.Remarks != nil {
+			t.Errorf("deliveries[%d] = %+v, want ToStop %d without remarks", i, d, i+1)
+		}
+	}
+	for i, stop := range got.Stops {
+		addr, ok := stop.Addresses[LocaleHongKongZH]
+		if !ok || addr.Country != CityCodeHongKongHongKong.GetLLMCountry() {
+			t.Errorf("stops[%d].addresses = %+v, want a %s address in %s", i, stop.Addresses, LocaleHongKongZH, CityCodeHongKongHongKong.GetLLMCountry())
+		}
+	}
+	if got.ScheduleAt != nil || got.SpecialRequests != nil {
+		t.Errorf("Build() = %+v, want an immediate order without special requests", got)
+	}
+}
+
+func TestQuoteBuilderErrors(t *testing.T) {
+	q := testQuotation()
+	pickup, drop := q.Stops[0].Location, q.Stops[1].Location
+	contact := q.RequesterContact
+	tests := []struct {
+		name    string
+		builder *QuoteBuilder
+		want    error
+	}{
+		{"unknown city", NewQuote("XX_XXX").Service(ServiceTypeVan), errInvalidCountry},
+		{"locale of another country", NewQuote(CityCodePhilippinesManila).Locale(LocaleHongKongZH), errInvalidLocale},
+		{"no pickup", NewQuote(CityCodePhilippinesManila).Service(ServiceTypeVan).DropOff(drop, "Pasig", contact, ""), errRequiredField},
+		{"no drop off", NewQuote(CityCodePhilippinesManila).Service(ServiceTypeVan).Pickup(pickup, "Makati", contact), errInsufficientStops},
+		{"no service", NewQuote(CityCodePhilippinesManila).Pickup(pickup, "Makati", contact).DropOff(drop, "Pasig", contact, ""), errRequiredField},
+		{"schedule in the past", NewQuote(CityCodePhilippinesManila).
+			Clock(func() time.Time { return testNow }).
+			Service(ServiceTypeVan).
+			Pickup(pickup, "Makati", contact).
+			DropOff(drop, "Pasig", contact, "").
+			Schedule(testNow.Add(-time.Minute)), errInvalidScheduleTime},
+	}
+	for _, tt := range tests {
+		if _, err := tt.builder.Build(); !errors.Is(err, tt.want) {
+			t.Errorf("Build(%s) = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestClientNewQuoteUsesClientClock(t *testing.T) {
+	// The client clock runs a day behind the local one.
+	clock := time.Now().Add(-24 * time.Hour)
+	c := testClient(t, nil, WithClock(func() time.Time { return clock }))
+	q := testQuotation()
+	build := func(b *QuoteBuilder, at time.Time) error {
+		_, err := b.Service(ServiceTypeVan).
+			Pickup(q.Stops[0].Location, "Makati", q.RequesterContact).
+			DropOff(q.Stops[1].Location, "Pasig", q.RequesterContact, "").
+			Schedule(at).
+			Build()
+		return err
+	}
+	if err := build(c.NewQuote(CityCodePhilippinesManila), clock.Add(time.Hour)); err != nil {
+		t.Errorf("Build() of a schedule after the client clock = %v", err)
+	}
+	if err := build(c.NewQuote(CityCodePhilippinesManila), clock.Add(-time.Hour)); !errors.Is(err, errInvalidScheduleTime) {
+		t.Errorf("Build() of a schedule before the client clock = %v, want %v", err, errInvalidScheduleTime)
+	}
+	if err := build(NewQuote(CityCodePhilippinesManila), clock.Add(time.Hour)); !errors.Is(err, errInvalidScheduleTime) {
+		t.Errorf("Build() of a schedule before the local clock = %v, want %v", err, errInvalidScheduleTime)
+	}
+}
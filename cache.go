@@ -0,0 +1,115 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ttlCache is an in-memory cache whose entries expire after a fixed time.
+type ttlCache struct {
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]ttlEntry
+	sets    int
+}
+
+type ttlEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// ttlCachePurgeInterval is the number of writes between sweeps of expired entries.
+const ttlCachePurgeInterval = 256
+
+func newTTLCache(now func() time.Time) *ttlCache {
+	return &ttlCache{now: now, entries: map[string]ttlEntry{}}
+}
+
+func (c *ttlCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (c *ttlCache) set(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	c.entries[key] = ttlEntry{value: value, expires: now.Add(ttl)}
+	if c.sets++; c.sets%ttlCachePurgeInterval == 0 {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+}
+
+func (c *ttlCache) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// flightGroup runs at most one call per key at a time; concurrent callers with the same key wait
+// for and share the result of the call in flight. The call runs with the context of the caller
+// which started it; if it fails because that context ended, waiters whose own context is still live
+// start the call again instead of sharing the failure.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	for {
+		g.mu.Lock()
+		if g.calls == nil {
+			g.calls = map[string]*flightCall{}
+		}
+		call, ok := g.calls[key]
+		if !ok {
+			break
+		}
+		g.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if isContextError(call.err) && ctx.Err() == nil {
+			continue
+		}
+		return call.value, call.err
+	}
+	call := &flightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.value, call.err = fn(ctx)
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(call.done)
+	return call.value, call.err
+}
+
+// isContextError reports whether the error is due to a canceled or expired context.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
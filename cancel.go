@@ -0,0 +1,158 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CancellationPolicy is the cancellation policy of a market.
+type CancellationPolicy struct {
+	// Statuses are the statuses in which orders may be canceled.
+	Statuses []OrderStatus
+	// DriverGracePeriod is how long after a driver was assigned an ON_GOING order may still be
+	// canceled. Zero means no limit.
+	DriverGracePeriod time.Duration
+}
+
+// DefaultCancellationPolicy allows canceling until 5 minutes after a driver accepted the order.
+// It applies to markets without an entry in CancellationPolicies.
+var DefaultCancellationPolicy = CancellationPolicy{
+	Statuses:          []OrderStatus{OrderStatusAssigningDriver, OrderStatusOngoing},
+	DriverGracePeriod: 5 * time.Minute,
+}
+
+// CancellationPolicies are the cancellation policies of each market. Every market currently
+// publishes the rule of DefaultCancellationPolicy; a client configured WithCancellationPolicy
+// overrides the entry of its market, e.g. for an account with negotiated terms.
+var CancellationPolicies = map[LLMCountry]CancellationPolicy{
+	CityCodeBrasilSaoPaulo.GetLLMCountry():      DefaultCancellationPolicy,
+	CityCodeBrasilRioDeJaneiro.GetLLMCountry():  DefaultCancellationPolicy,
+	CityCodeHongKongHongKong.GetLLMCountry():    DefaultCancellationPolicy,
+	CityCodeIndiaBengaluru.GetLLMCountry():      DefaultCancellationPolicy,
+	CityCodeIndiaMumbai.GetLLMCountry():         DefaultCancellationPolicy,
+	CityCodeIndiaDelhi.GetLLMCountry():          DefaultCancellationPolicy,
+	CityCodeIndonesiaJakarata.GetLLMCountry():   DefaultCancellationPolicy,
+	CityCodeMalaysiaKualaLumpur.GetLLMCountry(): DefaultCancellationPolicy,
+	CityCodeMexicoMexico.GetLLMCountry():        DefaultCancellationPolicy,
+	CityCodePhilippinesManila.GetLLMCountry():   DefaultCancellationPolicy,
+	CityCodePhilippinesCebu.GetLLMCountry():     DefaultCancellationPolicy,
+	CityCodeSingaporeSingapore.GetLLMCountry():  DefaultCancellationPolicy,
+	CityCodeTaiwanTaipei.GetLLMCountry():        DefaultCancellationPolicy,
+	CityCodeThailandBangkok.GetLLMCountry():     DefaultCancellationPolicy,
+	CityCodeThailandPattaya.GetLLMCountry():     DefaultCancellationPolicy,
+	CityCodeVietnamHoChiMinh.GetLLMCountry():    DefaultCancellationPolicy,
+	CityCodeVietnamHanoi.GetLLMCountry():        DefaultCancellationPolicy,
+}
+
+// ErrCancelUnconfirmed is matched by errors.Is for every CancelUnconfirmedError.
+var ErrCancelUnconfirmed = errors.New("order canceled but not confirmed")
+
+// CancelUnconfirmedError is returned by SafeCancel when the cancellation was accepted but the order
+// could not be retrieved afterwards to confirm it.
+type CancelUnconfirmedError struct {
+	OrderID string
+	// Err is the error retrieving the order after the cancellation.
+	Err error
+}
+
+func (e *CancelUnconfirmedError) Error() string {
+	return fmt.Sprintf("%s: order %s: %s", ErrCancelUnconfirmed, e.OrderID, e.Err)
+}
+
+// Is reports whether target is ErrCancelUnconfirmed.
+func (e *CancelUnconfirmedError) Is(target error) bool {
+	return target == ErrCancelUnconfirmed
+}
+
+// Unwrap returns the error retrieving the order.
+func (e *CancelUnconfirmedError) Unwrap() error {
+	return e.Err
+}
+
+// WithCancellationPolicy configures a Lalamove API client with the cancellation policy of a market,
+// replacing its entry in CancellationPolicies for the orders of the client.
+func WithCancellationPolicy(market LLMCountry, policy CancellationPolicy) ClientOption {
+	return func(c *Client) error {
+		if c.cancellationPolicies == nil {
+			c.cancellationPolicies = map[LLMCountry]CancellationPolicy{}
+		}
+		c.cancellationPolicies[market] = policy
+		return nil
+	}
+}
+
+// cancellationPolicyFor returns the policy of the market of the city.
+func (c *Client) cancellationPolicyFor(city CityCode) CancellationPolicy {
+	market := city.GetLLMCountry()
+	if policy, ok := c.cancellationPolicies[market]; ok {
+		return policy
+	}
+	if policy, ok := CancellationPolicies[market]; ok {
+		return policy
+	}
+	return DefaultCancellationPolicy
+}
+
+// Evaluate returns nil if an order in the status may be canceled at now, or an error wrapping
+// ERR_CANCELLATION_FORBIDDEN explaining why not. A zero driverAssignedAt means the assignment time
+// is unknown, in which case the grace period is not enforced and the API has the final say.
+func (p CancellationPolicy) Evaluate(status OrderStatus, driverAssignedAt, now time.Time) error {
+	allowed := false
+	for _, s := range p.Statuses {
+		allowed = allowed || s == status
+	}
+	if !allowed {
+		return fmt.Errorf("order is %s: %w", status, errCancellationForbidden)
+	}
+	if status == OrderStatusOngoing && p.DriverGracePeriod > 0 && !driverAssignedAt.IsZero() {
+		if elapsed := now.Sub(driverAssignedAt); elapsed > p.DriverGracePeriod {
+			return fmt.Errorf("driver assigned %s ago, limit is %s: %w",
+				elapsed.Truncate(time.Second), p.DriverGracePeriod, errCancellationForbidden)
+		}
+	}
+	return nil
+}
+
+// CanCancel returns nil if the order of the city may be canceled at the current time of the client
+// clock, or an error wrapping ERR_CANCELLATION_FORBIDDEN explaining why not. driverAssignedAt may be
+// zero to use OrderDetailsResponse.DriverAssignedAt.
+func (c *Client) CanCancel(city CityCode, d *OrderDetailsResponse, driverAssignedAt time.Time) error {
+	return c.cancellationPolicyFor(city).Evaluate(d.Status, d.driverAssignedAt(driverAssignedAt), c.now())
+}
+
+// driverAssignedAt returns the given assignment time, falling back to the one in the details.
+func (r *OrderDetailsResponse) driverAssignedAt(assignedAt time.Time) time.Time {
+	if assignedAt.IsZero() && r.DriverAssignedAt != nil {
+		return *r.DriverAssignedAt
+	}
+	return assignedAt
+}
+
+// SafeCancel cancels the order only if the cancellation policy of the market allows it, then
+// retrieves the order to confirm it is CANCELED. driverAssignedAt may be zero to use the assignment
+// time reported in the order details. The returned details are the latest known ones, also when
+// the cancellation is refused locally. If the cancellation was accepted but the order could not be
+// retrieved afterwards, the details from before the cancellation are returned with a
+// CancelUnconfirmedError.
+func (c *Client) SafeCancel(ctx context.Context, city CityCode, orderID string, driverAssignedAt time.Time) (*OrderDetailsResponse, error) {
+	details, err := c.OrderDetails(ctx, city, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.CanCancel(city, details, driverAssignedAt); err != nil {
+		return details, err
+	}
+	if err := c.CancelOrder(ctx, city, orderID); err != nil {
+		return details, err
+	}
+	canceled, err := c.OrderDetails(ctx, city, orderID)
+	if err != nil {
+		return details, &CancelUnconfirmedError{OrderID: orderID, Err: err}
+	}
+	if canceled.Status != OrderStatusCanceled {
+		return canceled, fmt.Errorf("order %s is %s after cancellation", orderID, canceled.Status)
+	}
+	return canceled, nil
+}
@@ -0,0 +1,138 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCanCancel(t *testing.T) {
+	assigned := testNow.Add(-DefaultCancellationPolicy.DriverGracePeriod)
+	tests := []struct {
+		name       string
+		status     OrderStatus
+		assignedAt time.Time
+		// reported is the assignment time in the order details.
+		reported *time.Time
+		now      time.Time
+		allowed  bool
+	}{
+		{"assigning driver", OrderStatusAssigningDriver, time.Time{}, nil, testNow, true},
+		{"ongoing within the grace period", OrderStatusOngoing, assigned.Add(time.Second), nil, testNow, true},
+		{"ongoing at the end of the grace period", OrderStatusOngoing, assigned, nil, testNow, true},
+		{"ongoing just after the grace period", OrderStatusOngoing, assigned, nil, testNow.Add(time.Nanosecond), false},
+		{"assignment time from the details", OrderStatusOngoing, time.Time{}, &assigned, testNow.Add(time.Second), false},
+		{"given assignment time overrides the details", OrderStatusOngoing, testNow, &assigned, testNow.Add(time.Second), true},
+		{"unknown assignment time", OrderStatusOngoing, time.Time{}, nil, testNow.Add(time.Hour), true},
+		{"picked up", OrderStatusPickedUp, time.Time{}, nil, testNow, false},
+		{"completed", OrderStatusCompleted, time.Time{}, nil, testNow, false},
+		{"already canceled", OrderStatusCanceled, time.Time{}, nil, testNow, false},
+	}
+	for _, tt := range tests {
+		now := tt.now
+		c := testClient(t, nil, WithClock(func() time.Time { return now }))
+		err := c.CanCancel(CityCodePhilippinesManila, &OrderDetailsResponse{Status: tt.status, DriverAssignedAt: tt.reported}, tt.assignedAt)
+		if tt.allowed && err != nil {
+			t.Errorf("CanCancel(%s) = %v, want nil", tt.name, err)
+		}
+		if !tt.allowed && !errors.Is(err, errCancellationForbidden) {
+			t.Errorf("CanCancel(%s) = %v, want %v", tt.name, err, errCancellationForbidden)
+		}
+	}
+}
+
+func TestCancellationPolicyPerMarket(t *testing.T) {
+	for _, country := range []Country{CountryBrasil, CountryHongKong, CountryIndia, CountryIndonesia, CountryMalaysia,
+		CountryMexico, CountryPhilippines, CountrySingapore, CountryTaiwan, CountryThailand, CountryVietnam} {
+		for _, city := range country.Cities {
+			if _, ok := CancellationPolicies[city.GetLLMCountry()]; !ok {
+				t.Errorf("no cancellation policy for %s", city.GetLLMCountry())
+			}
+		}
+	}
+
+	// A policy configured for Manila applies to Manila only, not to the rest of the Philippines.
+	strict := CancellationPolicy{Statuses: []OrderStatus{OrderStatusAssigningDriver}}
+	c := testClient(t, nil, WithCancellationPolicy(CityCodePhilippinesManila.GetLLMCountry(), strict))
+	ongoing := &OrderDetailsResponse{Status: OrderStatusOngoing}
+	if err := c.CanCancel(CityCodePhilippinesManila, ongoing, testNow); !errors.Is(err, errCancellationForbidden) {
+		t.Errorf("CanCancel() in Manila = %v, want %v", err, errCancellationForbidden)
+	}
+	if err := c.CanCancel(CityCodePhilippinesCebu, ongoing, testNow); err != nil {
+		t.Errorf("CanCancel() in Cebu = %v, want nil", err)
+	}
+	if err := c.CanCancel("XX_XXX", ongoing, testNow); err != nil {
+		t.Errorf("CanCancel() in an unknown market = %v, want the default policy to allow it", err)
+	}
+}
+
+// cancelServer answers OrderDetails with the status of the order, which becomes CANCELED when the
+// order is canceled, unless confirm fails.
+type cancelServer struct {
+	status     OrderStatus
+	assignedAt time.Time
+	cancels    int
+	// cancelStatus is the status of the cancellation response.
+	cancelStatus int
+	// confirmFails fails OrderDetails once the order was canceled.
+	confirmFails bool
+	// ignoresCancel keeps the status unchanged on cancellation.
+	ignoresCancel bool
+}
+
+func (s *cancelServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPut {
+		s.cancels++
+		if s.cancelStatus != http.StatusOK {
+			return stubResponse(req, s.cancelStatus, time.Time{}, `{"message":"ERR_CANCELLATION_FORBIDDEN"}`), nil
+		}
+		if !s.ignoresCancel {
+			s.status = OrderStatusCanceled
+		}
+		return stubResponse(req, http.StatusOK, time.Time{}, ""), nil
+	}
+	if s.cancels > 0 && s.confirmFails {
+		return stubResponse(req, http.StatusServiceUnavailable, time.Time{}, ""), nil
+	}
+	return stubResponse(req, http.StatusOK, time.Time{}, fmt.Sprintf(`{"status":%q,"price":{"amount":"163.00","currency":"PHP"},"driverId":"21712","driverAssignedAt":%q}`,
+		s.status, s.assignedAt.Format(time.RFC3339))), nil
+}
+
+func TestSafeCancel(t *testing.T) {
+	grace := DefaultCancellationPolicy.DriverGracePeriod
+	tests := []struct {
+		name        string
+		srv         *cancelServer
+		wantCancels int
+		wantStatus  OrderStatus
+		wantErr     error
+	}{
+		{"at the end of the grace period", &cancelServer{status: OrderStatusOngoing, assignedAt: testNow.Add(-grace), cancelStatus: http.StatusOK}, 1, OrderStatusCanceled, nil},
+		{"just after the grace period", &cancelServer{status: OrderStatusOngoing, assignedAt: testNow.Add(-grace - time.Second), cancelStatus: http.StatusOK}, 0, OrderStatusOngoing, errCancellationForbidden},
+		{"picked up", &cancelServer{status: OrderStatusPickedUp, assignedAt: testNow, cancelStatus: http.StatusOK}, 0, OrderStatusPickedUp, errCancellationForbidden},
+		{"refused by the API", &cancelServer{status: OrderStatusOngoing, assignedAt: testNow, cancelStatus: http.StatusConflict}, 1, OrderStatusOngoing, errCancellationForbidden},
+		{"unconfirmed", &cancelServer{status: OrderStatusOngoing, assignedAt: testNow, cancelStatus: http.StatusOK, confirmFails: true}, 1, OrderStatusOngoing, ErrCancelUnconfirmed},
+	}
+	for _, tt := range tests {
+		c := testClient(t, tt.srv)
+		details, err := c.SafeCancel(context.Background(), CityCodePhilippinesManila, "1001", time.Time{})
+		if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+			t.Errorf("SafeCancel(%s) = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if details == nil || details.Status != tt.wantStatus {
+			t.Errorf("SafeCancel(%s) details = %+v, want status %s", tt.name, details, tt.wantStatus)
+		}
+		if tt.srv.cancels != tt.wantCancels {
+			t.Errorf("SafeCancel(%s) sent %d cancellations, want %d", tt.name, tt.srv.cancels, tt.wantCancels)
+		}
+	}
+
+	srv := &cancelServer{status: OrderStatusOngoing, assignedAt: testNow, cancelStatus: http.StatusOK, ignoresCancel: true}
+	details, err := testClient(t, srv).SafeCancel(context.Background(), CityCodePhilippinesManila, "1001", time.Time{})
+	if err == nil || details.Status != OrderStatusOngoing {
+		t.Errorf("SafeCancel() of an order still ongoing = %+v, %v, want an error", details, err)
+	}
+}
@@ -0,0 +1,309 @@
+package lalamove
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Interaction is a single recorded request/response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// BodyEncodingText marks a recorded body which was not JSON and is stored as a JSON string of its text.
+const BodyEncodingText = "text"
+
+// RecordedRequest is the scrubbed form of a request sent to the Lalamove APIs.
+type RecordedRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Header http.Header     `json:"header,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+	// BodyEncoding is BodyEncodingText for bodies which were not JSON, and empty otherwise.
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
+}
+
+// RecordedResponse is the scrubbed form of a response received from the Lalamove APIs.
+type RecordedResponse struct {
+	StatusCode int             `json:"statusCode"`
+	Header     http.Header     `json:"header,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	// BodyEncoding is BodyEncodingText for bodies which were not JSON, and empty otherwise.
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
+}
+
+// Cassette is a list of interactions persisted as a JSON file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette reads a cassette file written by a Recorder.
+func LoadCassette(path string) (*Cassette, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cassette := &Cassette{}
+	if err := json.Unmarshal(b, cassette); err != nil {
+		return nil, err
+	}
+	return cassette, nil
+}
+
+// Save writes the cassette to path.
+func (c *Cassette) Save(path string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// scrubbedHeaders are dropped from every recorded request and response.
+var scrubbedHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// scrubbedFields are JSON object keys carrying personal information. Their values are
+// replaced by scrubbedValue wherever they appear in a recorded body.
+var scrubbedFields = map[string]bool{
+	"phone":         true,
+	"displayString": true,
+	"remarks":       true,
+	"plateNumber":   true,
+	"photo":         true,
+}
+
+// contactFields are JSON object keys carrying personal information only in contacts, which are
+// the objects with a phone number. Elsewhere they name cities or special requests and are kept.
+var contactFields = map[string]bool{
+	"name": true,
+}
+
+const scrubbedValue = "REDACTED"
+
+// Recorder is a http.RoundTripper which forwards requests to Transport and writes every
+// interaction to a cassette file, with credentials and personal information scrubbed.
+//
+// Use it with WithHTTPClient to capture sandbox traffic once, then replay it with a Replayer.
+type Recorder struct {
+	// Transport makes the actual requests. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	path     string
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder constructs a Recorder writing to the cassette file at path.
+func NewRecorder(path string, transport http.RoundTripper) *Recorder {
+	return &Recorder{Transport: transport, path: path}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			Path:   req.URL.RequestURI(),
+			Header: scrubHeader(req.Header),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     scrubHeader(resp.Header),
+		},
+	}
+	interaction.Request.Body, interaction.Request.BodyEncoding = scrubBody(reqBody)
+	interaction.Response.Body, interaction.Response.BodyEncoding = scrubBody(respBody)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if err := r.cassette.Save(r.path); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Replayer is a http.RoundTripper which answers requests from a cassette without touching the
+// network. Requests are matched on method, path and normalized body; each recorded interaction
+// is used at most once and in order. Unmatched requests fail with an error describing the request.
+type Replayer struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewReplayer constructs a Replayer from the cassette file at path.
+func NewReplayer(path string) (*Replayer, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Replayer{cassette: cassette, used: make([]bool, len(cassette.Interactions))}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	path := req.URL.RequestURI()
+	scrubbed, encoding := scrubBody(reqBody)
+	body := normalizeBody(scrubbed)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || interaction.Request.Method != req.Method || interaction.Request.Path != path ||
+			interaction.Request.BodyEncoding != encoding {
+			continue
+		}
+		if !bytes.Equal(normalizeBody(interaction.Request.Body), body) {
+			continue
+		}
+		r.used[i] = true
+		return interaction.Response.toHTTP(req), nil
+	}
+	return nil, fmt.Errorf("cassette: no recorded interaction matches %s %s %s", req.Method, path, body)
+}
+
+// Unused returns the recorded interactions which were never replayed.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []Interaction
+	for i, interaction := range r.cassette.Interactions {
+		if !r.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+func (r RecordedResponse) toHTTP(req *http.Request) *http.Response {
+	header := http.Header{}
+	for k, v := range r.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	body := []byte(r.Body)
+	if r.BodyEncoding == BodyEncodingText {
+		var s string
+		if json.Unmarshal(r.Body, &s) == nil {
+			body = []byte(s)
+		}
+	} else {
+		var compact bytes.Buffer
+		if json.Compact(&compact, r.Body) == nil {
+			body = compact.Bytes()
+		}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// readBody drains body and replaces it with a fresh reader over the same bytes.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	b, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = ioutil.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+func scrubHeader(h http.Header) http.Header {
+	scrubbed := h.Clone()
+	for _, k := range scrubbedHeaders {
+		scrubbed.Del(k)
+	}
+	// Bodies are re-encoded when recorded, so their recorded length would not match on replay.
+	scrubbed.Del("Date")
+	scrubbed.Del("Content-Length")
+	if len(scrubbed) == 0 {
+		return nil
+	}
+	return scrubbed
+}
+
+// scrubBody replaces personal information in a JSON body. Bodies which are not JSON are
+// recorded as JSON strings with the BodyEncodingText encoding.
+func scrubBody(b []byte) (json.RawMessage, string) {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil, ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		s, _ := json.Marshal(string(b))
+		return s, BodyEncodingText
+	}
+	scrubbed, err := json.Marshal(scrubValue(v))
+	if err != nil {
+		return nil, ""
+	}
+	return scrubbed, ""
+}
+
+func scrubValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		_, isContact := t["phone"]
+		for k, child := range t {
+			if _, ok := child.(string); ok && (scrubbedFields[k] || isContact && contactFields[k]) {
+				t[k] = scrubbedValue
+				continue
+			}
+			t[k] = scrubValue(child)
+		}
+	case []interface{}:
+		for i, child := range t {
+			t[i] = scrubValue(child)
+		}
+	}
+	return v
+}
+
+// normalizeBody re-encodes a JSON body so that key order and whitespace do not affect matching.
+func normalizeBody(b []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return bytes.TrimSpace(b)
+	}
+	normalized, _ := json.Marshal(v)
+	return normalized
+}
@@ -0,0 +1,120 @@
+package lalamove
+
+import (
+	"context"
+	"sort"
+)
+
+// CatalogDiff lists the differences between the live catalog of a country and the one compiled into
+// this package.
+type CatalogDiff struct {
+	Country CountryCode
+	// AddedCities are served live but missing from Country.Cities.
+	AddedCities []CityCode
+	// RemovedCities are in Country.Cities but no longer served.
+	RemovedCities []CityCode
+	// AddedLocales are offered live but missing from Country.Locales.
+	AddedLocales []Locale
+	// RemovedLocales are in Country.Locales but no longer offered.
+	RemovedLocales []Locale
+	// AddedServiceTypes are offered live but have no ServiceType constant.
+	AddedServiceTypes []ServiceType
+	// AddedSpecialRequests are offered live but have no SpecialRequest constant.
+	AddedSpecialRequests []SpecialRequest
+}
+
+// Empty reports whether the live catalog matches the compiled-in one.
+func (d *CatalogDiff) Empty() bool {
+	return len(d.AddedCities) == 0 && len(d.RemovedCities) == 0 &&
+		len(d.AddedLocales) == 0 && len(d.RemovedLocales) == 0 &&
+		len(d.AddedServiceTypes) == 0 && len(d.AddedSpecialRequests) == 0
+}
+
+// DiffCatalog compares the live cities of a country, as returned by GetCityInfo, with the
+// compiled-in Country.
+func DiffCatalog(country Country, live []CityInfo) *CatalogDiff {
+	diff := &CatalogDiff{Country: country.Code}
+
+	liveCities := map[CityCode]bool{}
+	liveLocales := map[Locale]bool{}
+	serviceTypes := map[ServiceType]bool{}
+	specialRequests := map[SpecialRequest]bool{}
+	for _, city := range live {
+		liveCities[city.Locode] = true
+		for _, locale := range city.Locales {
+			liveLocales[locale] = true
+		}
+		for _, st := range city.ServiceTypes {
+			if !st.Key.IsKnown() {
+				serviceTypes[st.Key] = true
+			}
+			for _, sr := range st.SpecialRequests {
+				if !sr.Name.IsKnown() {
+					specialRequests[sr.Name] = true
+				}
+			}
+		}
+	}
+
+	compiledCities := map[CityCode]bool{}
+	for _, city := range country.Cities {
+		compiledCities[city] = true
+		if !liveCities[city] {
+			diff.RemovedCities = append(diff.RemovedCities, city)
+		}
+	}
+	for city := range liveCities {
+		if !compiledCities[city] {
+			diff.AddedCities = append(diff.AddedCities, city)
+		}
+	}
+	compiledLocales := map[Locale]bool{}
+	for _, locale := range country.Locales {
+		compiledLocales[locale] = true
+		if !liveLocales[locale] {
+			diff.RemovedLocales = append(diff.RemovedLocales, locale)
+		}
+	}
+	for locale := range liveLocales {
+		if !compiledLocales[locale] {
+			diff.AddedLocales = append(diff.AddedLocales, locale)
+		}
+	}
+	for st := range serviceTypes {
+		diff.AddedServiceTypes = append(diff.AddedServiceTypes, st)
+	}
+	for sr := range specialRequests {
+		diff.AddedSpecialRequests = append(diff.AddedSpecialRequests, sr)
+	}
+
+	sort.Slice(diff.AddedCities, func(i, j int) bool { return diff.AddedCities[i] < diff.AddedCities[j] })
+	sort.Slice(diff.AddedLocales, func(i, j int) bool { return diff.AddedLocales[i] < diff.AddedLocales[j] })
+	sort.Slice(diff.AddedServiceTypes, func(i, j int) bool { return diff.AddedServiceTypes[i] < diff.AddedServiceTypes[j] })
+	sort.Slice(diff.AddedSpecialRequests, func(i, j int) bool { return diff.AddedSpecialRequests[i] < diff.AddedSpecialRequests[j] })
+	return diff
+}
+
+// SyncCatalog retrieves the live catalog of every country in AllCountriesByISOCode and reports the
+// differences with the compiled-in one. Countries whose catalog could not be retrieved are skipped
+// and the first error is returned along with the diffs of the others.
+func (c *Client) SyncCatalog(ctx context.Context) ([]*CatalogDiff, error) {
+	codes := make([]CountryCode, 0, len(AllCountriesByISOCode))
+	for code := range AllCountriesByISOCode {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+
+	var diffs []*CatalogDiff
+	var firstErr error
+	for _, code := range codes {
+		live, err := c.GetCityInfo(ctx, code)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		diffs = append(diffs, DiffCatalog(AllCountriesByISOCode[code], live))
+	}
+	return diffs, firstErr
+}
@@ -0,0 +1,138 @@
+package lalamove
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// liveCities is the live catalog of a country matching the compiled-in one.
+func liveCities(country Country) []CityInfo {
+	var cities []CityInfo
+	for _, city := range country.Cities {
+		cities = append(cities, CityInfo{
+			Locode:       city,
+			Locales:      country.Locales,
+			ServiceTypes: []CityServiceType{{Key: ServiceTypeMotorcycle, SpecialRequests: []CitySpecialRequest{{Name: SpecialRequestPurchaseService}}}},
+		})
+	}
+	return cities
+}
+
+func TestDiffCatalog(t *testing.T) {
+	tests := []struct {
+		name string
+		live func([]CityInfo) []CityInfo
+		want *CatalogDiff
+	}{
+		{
+			name: "unchanged",
+			live: func(cities []CityInfo) []CityInfo { return cities },
+			want: &CatalogDiff{Country: CountryCodeThailand},
+		},
+		{
+			name: "city added",
+			live: func(cities []CityInfo) []CityInfo {
+				return append(cities, CityInfo{Locode: "TH_CNX", Locales: CountryThailand.Locales})
+			},
+			want: &CatalogDiff{Country: CountryCodeThailand, AddedCities: []CityCode{"TH_CNX"}},
+		},
+		{
+			name: "city removed",
+			live: func(cities []CityInfo) []CityInfo { return cities[:1] },
+			want: &CatalogDiff{Country: CountryCodeThailand, RemovedCities: []CityCode{CityCodeThailandPattaya}},
+		},
+		{
+			name: "locales changed",
+			live: func(cities []CityInfo) []CityInfo {
+				for i := range cities {
+					cities[i].Locales = []Locale{LocaleThailandTH, "zh_TH", "fr_TH"}
+				}
+				return cities
+			},
+			want: &CatalogDiff{
+				Country:        CountryCodeThailand,
+				AddedLocales:   []Locale{"fr_TH", "zh_TH"},
+				RemovedLocales: []Locale{LocaleThailandEN},
+			},
+		},
+		{
+			name: "unknown service types and special requests",
+			live: func(cities []CityInfo) []CityInfo {
+				cities[0].ServiceTypes = append(cities[0].ServiceTypes,
+					CityServiceType{Key: "TRUCK_900", SpecialRequests: []CitySpecialRequest{{Name: "COLD_CHAIN"}, {Name: "HELPER_2"}}},
+					CityServiceType{Key: "BICYCLE"},
+				)
+				cities[1].ServiceTypes = append(cities[1].ServiceTypes, CityServiceType{Key: "TRUCK_900"})
+				return cities
+			},
+			want: &CatalogDiff{
+				Country:              CountryCodeThailand,
+				AddedServiceTypes:    []ServiceType{"BICYCLE", "TRUCK_900"},
+				AddedSpecialRequests: []SpecialRequest{"COLD_CHAIN", "HELPER_2"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got := DiffCatalog(CountryThailand, tt.live(liveCities(CountryThailand)))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DiffCatalog(%s) = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if got.Empty() != (tt.name == "unchanged") {
+			t.Errorf("DiffCatalog(%s).Empty() = %v", tt.name, got.Empty())
+		}
+	}
+}
+
+func TestSyncCatalog(t *testing.T) {
+	c := testClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		market := LLMCountry(req.Header.Get("X-LLM-Country"))
+		resp := GetCityInfoResponse{}
+		for _, country := range AllCountriesByISOCode {
+			for _, city := range liveCities(country) {
+				if city.Locode.GetLLMCountry() == market {
+					resp.Cities = append(resp.Cities, city)
+				}
+			}
+		}
+		switch resp.Cities[0].Locode.GetCountry().Code {
+		case CountryCodeVietnam:
+			return stubResponse(req, http.StatusUnauthorized, testNow, `{"message":"ERR_UNAUTHORIZED"}`), nil
+		case CountryCodeThailand:
+			resp.Cities = append(resp.Cities, CityInfo{Locode: "TH_CNX", Locales: CountryThailand.Locales})
+		}
+		body, err := json.Marshal(resp)
+		if err != nil {
+			return nil, err
+		}
+		return stubResponse(req, http.StatusOK, testNow, string(body)), nil
+	}))
+
+	diffs, err := c.SyncCatalog(context.Background())
+	if !errors.Is(err, errUnauthorized) {
+		t.Errorf("error = %v, want %v", err, errUnauthorized)
+	}
+	if len(diffs) != len(AllCountriesByISOCode)-1 {
+		t.Fatalf("got %d diffs, want one per country but Vietnam", len(diffs))
+	}
+	for i, diff := range diffs {
+		if i > 0 && diffs[i-1].Country >= diff.Country {
+			t.Errorf("diffs not sorted by country: %s before %s", diffs[i-1].Country, diff.Country)
+		}
+		switch diff.Country {
+		case CountryCodeVietnam:
+			t.Errorf("diff of %s whose catalog could not be retrieved", diff.Country)
+		case CountryCodeThailand:
+			if !reflect.DeepEqual(diff.AddedCities, []CityCode{"TH_CNX"}) {
+				t.Errorf("added cities of %s = %v, want [TH_CNX]", diff.Country, diff.AddedCities)
+			}
+		default:
+			if !diff.Empty() {
+				t.Errorf("diff of %s = %+v, want none", diff.Country, diff)
+			}
+		}
+	}
+}
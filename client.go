@@ -11,6 +11,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/twinj/uuid"
@@ -18,18 +19,54 @@ import (
 
 // Client may be used to make requests to the Lalamove APIs
 type Client struct {
+	// clockOffset is the correction in nanoseconds applied to the signing timestamp. It is
+	// accessed atomically and kept first for 64-bit alignment.
+	clockOffset int64
+
 	httpClient *http.Client
 	apiKey     string
 	secret     string
 	baseURL    string
+
+	environment *Environment
+
+	now            func() time.Time
+	newRequestID   func() string
+	skewCorrection bool
+
+	breaker *circuitBreaker
+
+	batchConcurrency int
+	detailsTTL       time.Duration
+	detailsCache     *ttlCache
+	detailsFlight    flightGroup
+
+	quotationCache  QuotationCache
+	quotationTTL    time.Duration
+	quotationFlight flightGroup
+
+	cancellationPolicies map[LLMCountry]CancellationPolicy
+
+	enumDecoding  EnumDecoding
+	onUnknownEnum func(typ, value string)
 }
 
+// clockSkewTolerance is the drift from the server clock below which signing timestamps are not corrected.
+const clockSkewTolerance = 30 * time.Second
+
 // ClientOption is the type of constructor options for NewClient(...).
 type ClientOption func(*Client) error
 
 // NewClient constructs a new Client which can make requests to the Lalamove APIs.
+// It fails if the base URL is the host of an Environment and the API key or secret was issued for
+// a different one.
 func NewClient(options ...ClientOption) (*Client, error) {
-	c := &Client{}
+	c := &Client{
+		now:            time.Now,
+		newRequestID:   func() string { return uuid.NewV4().String() },
+		skewCorrection: true,
+	}
+	c.detailsCache = newTTLCache(func() time.Time { return c.now() })
 	for _, option := range options {
 		err := option(c)
 		if err != nil {
@@ -42,6 +79,11 @@ func NewClient(options ...ClientOption) (*Client, error) {
 	if strings.TrimSpace(c.baseURL) == "" {
 		return nil, errBaseURLMissing
 	}
+	if env, ok := environmentForBaseURL(c.baseURL, c.environment); ok {
+		if err := env.checkCredentials(c.apiKey, c.secret); err != nil {
+			return nil, err
+		}
+	}
 	return c, nil
 }
 
@@ -80,29 +122,87 @@ func WithBaseURL(baseURL string) ClientOption {
 	}
 }
 
-func (c *Client) get(ctx context.Context, city CityCode, path string, apiReq interface{}, apiResp interface{}) error {
-	req, err := c.createRequest(city, http.MethodGet, path, apiReq)
-	if err != nil {
-		return err
+// WithClock configures a Lalamove API client with the source of the current time used to sign requests.
+func WithClock(now func() time.Time) ClientOption {
+	return func(c *Client) error {
+		c.now = now
+		return nil
 	}
-	return c.do(ctx, req, apiResp)
+}
+
+// WithRequestIDGenerator configures a Lalamove API client with the generator of X-Request-ID headers.
+func WithRequestIDGenerator(gen func() string) ClientOption {
+	return func(c *Client) error {
+		c.newRequestID = gen
+		return nil
+	}
+}
+
+// WithClockSkewCorrection enables or disables the automatic correction of the signing timestamp.
+// When enabled (the default), a 401 response whose Date header is off from the local clock by more
+// than 30 seconds adjusts the signing timestamp of all further requests and the request is retried once.
+func WithClockSkewCorrection(enabled bool) ClientOption {
+	return func(c *Client) error {
+		c.skewCorrection = enabled
+		return nil
+	}
+}
+
+func (c *Client) get(ctx context.Context, city CityCode, path string, apiReq interface{}, apiResp interface{}) error {
+	return c.send(ctx, city, http.MethodGet, path, apiReq, apiResp)
 }
 
 func (c *Client) post(ctx context.Context, city CityCode, path string, apiReq interface{}, apiResp interface{}) error {
-	req, err := c.createRequest(city, http.MethodPost, path, apiReq)
+	return c.send(ctx, city, http.MethodPost, path, apiReq, apiResp)
+}
+
+func (c *Client) put(ctx context.Context, city CityCode, path string, apiReq interface{}, apiResp interface{}) error {
+	return c.send(ctx, city, http.MethodPut, path, apiReq, apiResp)
+}
+
+func (c *Client) patch(ctx context.Context, city CityCode, path string, apiReq interface{}, apiResp interface{}) error {
+	return c.send(ctx, city, http.MethodPatch, path, apiReq, apiResp)
+}
+
+func (c *Client) delete(ctx context.Context, city CityCode, path string, apiReq interface{}, apiResp interface{}) error {
+	return c.send(ctx, city, http.MethodDelete, path, apiReq, apiResp)
+}
+
+// send signs and sends a request through the circuit breaker, if configured.
+func (c *Client) send(ctx context.Context, city CityCode, method, path string, apiReq interface{}, apiResp interface{}) error {
+	if c.breaker == nil {
+		return c.sendSigned(ctx, city, method, path, apiReq, apiResp)
+	}
+	key := circuitKey(city, method, path)
+	ticket, err := c.breaker.allow(key)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	return c.do(ctx, req, apiResp)
+	err = c.sendSigned(ctx, city, method, path, apiReq, apiResp)
+	c.breaker.record(key, ticket, err)
+	return err
 }
 
-func (c *Client) put(ctx context.Context, city CityCode, path string, apiReq interface{}, apiResp interface{}) error {
-	req, err := c.createRequest(city, http.MethodPut, path, apiReq)
+// sendSigned signs and sends a request, retrying once if the signing timestamp had to be corrected.
+func (c *Client) sendSigned(ctx context.Context, city CityCode, method, path string, apiReq interface{}, apiResp interface{}) error {
+	err := c.sendOnce(ctx, city, method, path, apiReq, apiResp)
+	if err == errClockSkew {
+		err = c.sendOnce(ctx, city, method, path, apiReq, apiResp)
+	}
+	if err == errClockSkew {
+		return &HTTPError{StatusCode: http.StatusUnauthorized, Err: errUnauthorized}
+	}
+	return err
+}
+
+func (c *Client) sendOnce(ctx context.Context, city CityCode, method, path string, apiReq interface{}, apiResp interface{}) error {
+	req, err := c.createRequest(city, method, path, apiReq)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	if method != http.MethodGet {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	return c.do(ctx, req, apiResp)
 }
 
@@ -117,7 +217,7 @@ func (c *Client) createRequest(city CityCode, method, path string, apiReq interf
 	}
 	auth := c.generateAuth(method, path, bodyBytes)
 	req.Header.Set("Authorization", auth)
-	req.Header.Set("X-Request-ID", uuid.NewV4().String())
+	req.Header.Set("X-Request-ID", c.newRequestID())
 	req.Header.Set("X-LLM-Country", string(city.GetLLMCountry()))
 	return req, nil
 }
@@ -132,18 +232,53 @@ func (c *Client) do(ctx context.Context, req *http.Request, apiResp interface{})
 		return err
 	}
 	defer resp.Body.Close()
-	return decodeResponse(resp, apiResp)
+	if resp.StatusCode == http.StatusUnauthorized && c.correctClockSkew(resp.Header.Get("Date")) {
+		return errClockSkew
+	}
+	if err := decodeResponse(resp, apiResp); err != nil {
+		return err
+	}
+	return c.checkEnums(apiResp)
+}
+
+// signingTime returns the current time as seen by the Lalamove servers.
+func (c *Client) signingTime() time.Time {
+	return c.now().Add(time.Duration(atomic.LoadInt64(&c.clockOffset)))
+}
+
+// correctClockSkew adjusts the signing timestamp to the server Date header and reports whether the
+// correction changed by more than clockSkewTolerance, i.e. whether a retry may succeed.
+func (c *Client) correctClockSkew(date string) bool {
+	if !c.skewCorrection || date == "" {
+		return false
+	}
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return false
+	}
+	drift := serverTime.Sub(c.now())
+	offset := time.Duration(atomic.LoadInt64(&c.clockOffset))
+	if diff := drift - offset; diff < clockSkewTolerance && diff > -clockSkewTolerance {
+		return false
+	}
+	atomic.StoreInt64(&c.clockOffset, int64(drift))
+	return true
 }
 
 func (c *Client) generateAuth(method, path string, body []byte) string {
-	now := time.Now().UnixNano() / int64(time.Millisecond)
-	rawSignature := fmt.Sprintf("%d\r\n%s\r\n%s\r\n\r\n%s", now, method, path, string(body))
-	mac := hmac.New(sha256.New, []byte(c.secret))
-	mac.Write([]byte(rawSignature))
-	signature := hex.EncodeToString(mac.Sum(nil))
+	now := c.signingTime().UnixNano() / int64(time.Millisecond)
+	signature := sign(c.secret, now, method, path, body)
 	return fmt.Sprintf("hmac %s:%d:%s", c.apiKey, now, signature)
 }
 
+// sign computes the HMAC signature of a request, as used both for API requests and webhooks.
+func sign(secret string, timestamp int64, method, path string, body []byte) string {
+	rawSignature := fmt.Sprintf("%d\r\n%s\r\n%s\r\n\r\n%s", timestamp, method, path, string(body))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(rawSignature))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 func marshalRequest(apiReq interface{}) (io.Reader, []byte, error) {
 	if apiReq == nil {
 		return nil, nil, nil
@@ -156,18 +291,20 @@ func marshalRequest(apiReq interface{}) (io.Reader, []byte, error) {
 }
 
 func decodeResponse(resp *http.Response, apiResp interface{}) error {
-	if resp.StatusCode == 429 {
-		return errTooManyRequests
-	} else if resp.StatusCode == 401 {
-		return errUnauthorized
-	} else if resp.StatusCode == 402 || resp.StatusCode == 409 {
-		errResp := &ErrorResponse{}
-		if err := json.NewDecoder(resp.Body).Decode(errResp); err != nil {
-			return err
+	if resp.StatusCode >= 400 {
+		err := errUnknownError
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests:
+			err = errTooManyRequests
+		case http.StatusUnauthorized:
+			err = errUnauthorized
+		case http.StatusPaymentRequired, http.StatusConflict:
+			errResp := &ErrorResponse{}
+			if json.NewDecoder(resp.Body).Decode(errResp) == nil {
+				err = wrapAPIError(errResp)
+			}
 		}
-		return wrapAPIError(errResp)
-	} else if resp.StatusCode >= 400 {
-		return errUnknownError
+		return &HTTPError{StatusCode: resp.StatusCode, Err: err}
 	}
 	if apiResp == nil {
 		return nil
@@ -0,0 +1,141 @@
+package lalamove
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubResponse constructs a response to req with the given status, Date header and JSON body.
+func stubResponse(req *http.Request, status int, date time.Time, body string) *http.Response {
+	header := http.Header{"Content-Type": {"application/json"}}
+	if !date.IsZero() {
+		header.Set("Date", date.Format(http.TimeFormat))
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestRequestSigning(t *testing.T) {
+	var got *http.Request
+	c := testClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		got = req
+		return stubResponse(req, http.StatusOK, time.Time{}, ""), nil
+	}))
+	if err := c.AddPriorityFee(context.Background(), CityCodePhilippinesManila, "1001", Money{Amount: "20", Currency: "PHP"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := testNow.UnixNano() / int64(time.Millisecond)
+	body := `{"priorityFee":{"amount":"20","currency":"PHP"}}`
+	mac := hmac.New(sha256.New, []byte("sk_test_0123456789"))
+	fmt.Fprintf(mac, "%d\r\nPOST\r\n/v2/orders/1001/priority-fee\r\n\r\n%s", ts, body)
+	want := fmt.Sprintf("hmac pk_test_0123456789:%d:%s", ts, hex.EncodeToString(mac.Sum(nil)))
+	if auth := got.Header.Get("Authorization"); auth != want {
+		t.Errorf("Authorization = %s, want %s", auth, want)
+	}
+	if id := got.Header.Get("X-Request-ID"); id != testRequestID {
+		t.Errorf("X-Request-ID = %s, want %s", id, testRequestID)
+	}
+	if country := got.Header.Get("X-LLM-Country"); country != "PH_MNL" {
+		t.Errorf("X-LLM-Country = %s, want PH_MNL", country)
+	}
+	if host := got.URL.Host; host != "sandbox-rest.lalamove.com" {
+		t.Errorf("host = %s, want the sandbox host", host)
+	}
+}
+
+func TestClockSkewCorrection(t *testing.T) {
+	const unauthorized = `{"message":"ERR_UNAUTHORIZED"}`
+	serverNow := testNow.Add(5 * time.Minute)
+	// reply is the status and Date header of a response.
+	type reply struct {
+		status int
+		date   time.Time
+	}
+	tests := []struct {
+		name    string
+		options []ClientOption
+		// responses are answered in order.
+		responses []reply
+		// signedAt are the signing times of the requests sent, relative to testNow.
+		signedAt []time.Duration
+		wantErr  error
+	}{
+		{
+			name:      "retried with the server time",
+			responses: []reply{{http.StatusUnauthorized, serverNow}, {http.StatusOK, serverNow}},
+			signedAt:  []time.Duration{0, 5 * time.Minute},
+		},
+		{
+			name:      "retried once",
+			responses: []reply{{http.StatusUnauthorized, serverNow}, {http.StatusUnauthorized, serverNow.Add(5 * time.Minute)}},
+			signedAt:  []time.Duration{0, 5 * time.Minute},
+			wantErr:   errUnauthorized,
+		},
+		{
+			name:      "not retried within tolerance",
+			responses: []reply{{http.StatusUnauthorized, testNow.Add(5 * time.Second)}},
+			signedAt:  []time.Duration{0},
+			wantErr:   errUnauthorized,
+		},
+		{
+			name:      "not retried when disabled",
+			options:   []ClientOption{WithClockSkewCorrection(false)},
+			responses: []reply{{http.StatusUnauthorized, serverNow}},
+			signedAt:  []time.Duration{0},
+			wantErr:   errUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var signedAt []time.Duration
+			c := testClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				signedAt = append(signedAt, authTime(t, req).Sub(testNow))
+				if len(signedAt) > len(tt.responses) {
+					return nil, fmt.Errorf("unexpected request %d", len(signedAt))
+				}
+				resp := tt.responses[len(signedAt)-1]
+				body := unauthorized
+				if resp.status == http.StatusOK {
+					body = `{"status":"ON_GOING","price":{"amount":"108.00","currency":"PHP"}}`
+				}
+				return stubResponse(req, resp.status, resp.date, body), nil
+			}), tt.options...)
+			_, err := c.OrderDetails(context.Background(), CityCodePhilippinesManila, "1001")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if fmt.Sprint(signedAt) != fmt.Sprint(tt.signedAt) {
+				t.Errorf("requests signed at %v, want %v", signedAt, tt.signedAt)
+			}
+		})
+	}
+}
+
+// authTime returns the signing time of the Authorization header of the request.
+func authTime(t *testing.T, req *http.Request) time.Time {
+	t.Helper()
+	parts := strings.Split(strings.TrimPrefix(req.Header.Get("Authorization"), "hmac "), ":")
+	if len(parts) != 3 {
+		t.Fatalf("malformed Authorization %q", req.Header.Get("Authorization"))
+	}
+	ms, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+
+	lalamove "github.com/rgaquino/lalamove-go"
+)
+
+func fakeServer(args []string) error {
+	fs := flag.NewFlagSet("fake-server", flag.ExitOnError)
+	scenario := fs.String("scenario", "", "JSON scenario file to play")
+	addr := fs.String("addr", "localhost:8081", "address to listen on")
+	fs.Parse(args)
+	if *scenario == "" {
+		return fmt.Errorf("fake-server: -scenario is required")
+	}
+
+	s, err := lalamove.LoadScenario(*scenario)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("playing scenario %q on http://%s\n", s.Name, *addr)
+	return http.ListenAndServe(*addr, lalamove.NewFakeServer(s))
+}
@@ -0,0 +1,53 @@
+// Command lalamove is a set of development tools for the Lalamove API.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+const usage = `usage: lalamove <command> [flags]
+
+commands:
+  webhook-sim   send signed webhook events to a local URL
+  fake-server   serve a scripted scenario in place of the Lalamove APIs
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "webhook-sim":
+		err = webhookSim(os.Args[2:])
+	case "fake-server":
+		err = fakeServer(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lalamove:", err)
+		os.Exit(1)
+	}
+}
+
+// interruptContext returns a context canceled on the first interrupt.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt)
+	go func() {
+		select {
+		case <-ch:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(ch)
+	}()
+	return ctx, cancel
+}
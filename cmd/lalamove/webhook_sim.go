@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	lalamove "github.com/rgaquino/lalamove-go"
+)
+
+func webhookSim(args []string) error {
+	fs := flag.NewFlagSet("webhook-sim", flag.ExitOnError)
+	url := fs.String("url", "http://localhost:8080/webhook", "webhook URL to deliver to")
+	apiKey := fs.String("key", os.Getenv("LALAMOVE_API_KEY"), "API key set on the events")
+	secret := fs.String("secret", os.Getenv("LALAMOVE_SECRET"), "API secret the events are signed with")
+	orderID := fs.String("order", "sim-order-1", "order ID of the events")
+	driverID := fs.String("driver", "sim-driver-1", "driver ID set once the order is ON_GOING")
+	statuses := fs.String("statuses", "ASSIGNING_DRIVER,ON_GOING,PICKED_UP,COMPLETED", "comma separated order statuses to deliver")
+	interval := fs.Duration("interval", time.Second, "time between status changes")
+	duplicate := fs.Float64("duplicate", 0, "probability of delivering an event twice")
+	delay := fs.Float64("delay", 0, "probability of delaying an event")
+	maxDelay := fs.Duration("max-delay", 3*time.Second, "longest delay of a delayed event")
+	malformed := fs.Float64("malformed", 0, "probability of also delivering a malformed or badly signed event")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "seed of the random choices")
+	fs.Parse(args)
+
+	var seq []lalamove.OrderStatus
+	for _, s := range strings.Split(*statuses, ",") {
+		status := lalamove.OrderStatus(strings.TrimSpace(s))
+		if !status.IsKnown() {
+			return fmt.Errorf("unknown order status %q", s)
+		}
+		seq = append(seq, status)
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+	sim := &lalamove.WebhookSimulator{URL: *url, APIKey: *apiKey, Secret: *secret}
+	fmt.Printf("seed %d\n", *seed)
+	deliveries, err := sim.Simulate(ctx, *orderID, seq, lalamove.SimulationOptions{
+		DriverID:      *driverID,
+		Interval:      *interval,
+		DuplicateRate: *duplicate,
+		DelayRate:     *delay,
+		MaxDelay:      *maxDelay,
+		MalformedRate: *malformed,
+		Seed:          *seed,
+	})
+	for _, d := range deliveries {
+		status := "-"
+		if order, err := d.Event.Order(); err == nil {
+			status = string(order.Status)
+		}
+		result := fmt.Sprint(d.StatusCode)
+		if d.Err != nil {
+			result = d.Err.Error()
+		}
+		fmt.Printf("%-13s %-36s %-16s %s\n", d.Kind, d.Event.EventID, status, result)
+	}
+	return err
+}
@@ -0,0 +1,226 @@
+package lalamove
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// update regenerates the schema and golden files of the contract checks instead of comparing them.
+var update = flag.Bool("update", false, "regenerate the contract schema and golden files")
+
+// contractDir holds the contract files, relative to the package directory in which tests run.
+const contractDir = "testdata/contract"
+
+// cassetteDir holds the recorded cassettes the contract samples are taken from.
+const cassetteDir = "testdata/cassettes"
+
+// contractModels are the request and response types whose wire format is pinned by TestContracts.
+var contractModels = []interface{}{
+	GetQuotationRequest{},
+	GetQuotationResponse{},
+	PlaceOrderRequest{},
+	PlaceOrderResponse{},
+	OrderDetailsResponse{},
+	AddPriorityFeeRequest{},
+	ChangeDriverRequest{},
+	EditOrderRequest{},
+	DriverDetailsResponse{},
+	DriverLocationResponse{},
+	GetCityInfoResponse{},
+	Webhook{},
+	ErrorResponse{},
+}
+
+// contractEndpoints are the types of the request and successful response bodies of each endpoint,
+// named as by routeFake. Error responses are ErrorResponse.
+var contractEndpoints = map[string]struct{ request, response string }{
+	"GetQuotation":   {"GetQuotationRequest", "GetQuotationResponse"},
+	"PlaceOrder":     {"PlaceOrderRequest", "PlaceOrderResponse"},
+	"OrderDetails":   {"", "OrderDetailsResponse"},
+	"EditOrder":      {"EditOrderRequest", "OrderDetailsResponse"},
+	"CancelOrder":    {"", ""},
+	"AddPriorityFee": {"AddPriorityFeeRequest", ""},
+	"DriverDetails":  {"", "DriverDetailsResponse"},
+	"ChangeDriver":   {"ChangeDriverRequest", ""},
+	"DriverLocation": {"", "DriverLocationResponse"},
+	"GetCityInfo":    {"", "GetCityInfoResponse"},
+	"SetWebhook":     {"Webhook", ""},
+	"GetWebhook":     {"", "Webhook"},
+}
+
+// TestContracts checks the wire format of every request and response type against the contract
+// files under testdata/contract, one of each per type:
+//
+//	schema/<Type>.json  the JSON Schema generated from the Go type
+//	golden/<Type>.json  the samples decoded into the Go type and encoded again
+//
+// The samples are the bodies of that type recorded in the cassettes under testdata/cassettes, one
+// per distinct set of fields, validated against the schema. Every type must have a sample.
+//
+// A change to a type which alters its schema or its encoding of the samples, or a new recording
+// with other fields, fails the test until the contract files are regenerated with -update.
+func TestContracts(t *testing.T) {
+	samples, err := contractSamples(cassetteDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range contractModels {
+		model := model
+		name := reflect.TypeOf(model).Name()
+		t.Run(name, func(t *testing.T) {
+			if err := checkContract(contractDir, name, model, samples[name], *update); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// contractSamples collects the JSON bodies of the cassettes in dir by type name, keeping the first
+// body of each distinct set of fields.
+func contractSamples(dir string) (map[string][]json.RawMessage, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	samples := map[string][]json.RawMessage{}
+	shapes := map[string]bool{}
+	add := func(name string, body json.RawMessage) {
+		if name == "" || len(body) == 0 {
+			return
+		}
+		shape := name + " " + sampleShape(body)
+		if !shapes[shape] {
+			shapes[shape] = true
+			samples[name] = append(samples[name], body)
+		}
+	}
+	for _, path := range paths {
+		c, err := LoadCassette(path)
+		if err != nil {
+			return nil, err
+		}
+		for i, in := range c.Interactions {
+			endpoint, _, _ := routeFake(in.Request.Method, in.Request.Path)
+			types, ok := contractEndpoints[endpoint]
+			if !ok {
+				return nil, fmt.Errorf("%s: interaction %d: no contract for %s %s", path, i, in.Request.Method, in.Request.Path)
+			}
+			if in.Request.BodyEncoding == "" {
+				add(types.request, in.Request.Body)
+			}
+			if in.Response.BodyEncoding == "" {
+				if in.Response.StatusCode >= 400 {
+					add("ErrorResponse", in.Response.Body)
+				} else {
+					add(types.response, in.Response.Body)
+				}
+			}
+		}
+	}
+	return samples, nil
+}
+
+// sampleShape describes the fields of a JSON value and the JSON types of their values.
+func sampleShape(body json.RawMessage) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	fields := map[string]bool{}
+	var walk func(path string, v interface{})
+	walk = func(path string, v interface{}) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			fields[path+":object"] = true
+			for k, child := range t {
+				walk(path+"."+k, child)
+			}
+		case []interface{}:
+			fields[path+":array"] = true
+			for _, child := range t {
+				walk(path+"[]", child)
+			}
+		default:
+			fields[fmt.Sprintf("%s:%T", path, v)] = true
+		}
+	}
+	walk("", v)
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, " ")
+}
+
+func checkContract(dir, name string, model interface{}, samples []json.RawMessage, update bool) error {
+	schema := schemaFor(model)
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := checkGolden(filepath.Join(dir, "schema", name+".json"), b, update); err != nil {
+		return err
+	}
+
+	if len(samples) == 0 {
+		return fmt.Errorf("no sample in %s", cassetteDir)
+	}
+	var encoded []json.RawMessage
+	for i, sample := range samples {
+		if err := schema.check(sample); err != nil {
+			return fmt.Errorf("sample %d does not match the schema: %w", i, err)
+		}
+		v := reflect.New(reflect.TypeOf(model))
+		if err := json.Unmarshal(sample, v.Interface()); err != nil {
+			return fmt.Errorf("sample %d: %w", i, err)
+		}
+		out, err := json.Marshal(v.Interface())
+		if err != nil {
+			return fmt.Errorf("sample %d: %w", i, err)
+		}
+		if err := schema.check(out); err != nil {
+			return fmt.Errorf("sample %d encodes outside the schema: %w", i, err)
+		}
+		encoded = append(encoded, out)
+	}
+	b, err = json.MarshalIndent(encoded, "", "  ")
+	if err != nil {
+		return err
+	}
+	return checkGolden(filepath.Join(dir, "golden", name+".json"), b, update)
+}
+
+// checkGolden compares got to the golden file at path, or rewrites the file if update is set.
+func checkGolden(path string, got []byte, update bool) error {
+	got = append(got, '\n')
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, got, 0644)
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(got, want) {
+		return nil
+	}
+	gotLines, wantLines := strings.Split(string(got), "\n"), strings.Split(string(want), "\n")
+	for i := 0; i < len(gotLines) && i < len(wantLines); i++ {
+		if gotLines[i] != wantLines[i] {
+			return fmt.Errorf("%s:%d: got %s, want %s", path, i+1, strings.TrimSpace(gotLines[i]), strings.TrimSpace(wantLines[i]))
+		}
+	}
+	return fmt.Errorf("%s: got %d lines, want %d", path, len(gotLines), len(wantLines))
+}
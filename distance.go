@@ -0,0 +1,139 @@
+package lalamove
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// earthRadius is the mean radius of the Earth in meters.
+const earthRadius = 6371008.8
+
+// defaultRoadDistanceFactor is used for cities without an entry in RoadDistanceFactors.
+const defaultRoadDistanceFactor = 1.4
+
+// defaultAverageSpeed is used for service types without an entry in AverageSpeeds.
+const defaultAverageSpeed = 20.0
+
+// RoadDistanceFactors are the typical ratios of road distance to great-circle distance per city.
+var RoadDistanceFactors = map[CityCode]float64{
+	CityCodeHongKongHongKong:   1.5,
+	CityCodeSingaporeSingapore: 1.3,
+	CityCodeTaiwanTaipei:       1.3,
+}
+
+// AverageSpeeds are the typical urban speeds in km/h per service type, stops excluded.
+var AverageSpeeds = map[ServiceType]float64{
+	ServiceTypeMotorcycle:   25,
+	ServiceTypeLalago:       25,
+	ServiceTypeLalapro:      25,
+	ServiceTypeCar:          22,
+	ServiceTypeMPV:          22,
+	ServiceTypeMinivan:      20,
+	ServiceTypeUV:           20,
+	ServiceTypeVan:          20,
+	ServiceType4x4:          20,
+	ServiceTypeThreeWheeler: 18,
+	ServiceTypeTataAce7:     18,
+	ServiceTypeTataAce8:     18,
+	ServiceTypeTruck175:     18,
+	ServiceTypeTruck330:     16,
+	ServiceTypeTruck550:     16,
+}
+
+// DistanceTo returns the great-circle distance in meters between two coordinates.
+func (p LatLng) DistanceTo(q LatLng) float64 {
+	lat1, lat2 := p.Lat*math.Pi/180, q.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (q.Lng - p.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Distance returns the great-circle distance in meters between two locations.
+func Distance(a, b Location) (float64, error) {
+	p, err := a.LatLng()
+	if err != nil {
+		return 0, err
+	}
+	q, err := b.LatLng()
+	if err != nil {
+		return 0, err
+	}
+	return p.DistanceTo(q), nil
+}
+
+// PathLength returns the great-circle length in meters of the path visiting the stops in order.
+func PathLength(stops []Waypoint) (float64, error) {
+	legs, err := legDistances(stops)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for _, leg := range legs {
+		total += leg
+	}
+	return total, nil
+}
+
+// RouteEstimate is an offline estimate of the route of a quotation request.
+type RouteEstimate struct {
+	// Legs are the great-circle distances in meters between consecutive stops.
+	Legs []float64
+	// Distance is the great-circle length of the route in meters.
+	Distance float64
+	// RoadDistance is Distance scaled by the road distance factor of the city.
+	RoadDistance float64
+	// Duration is the driving time over RoadDistance at the average speed of the service type.
+	Duration time.Duration
+}
+
+// EstimateRoute estimates the length and driving time of the request without calling the API.
+func EstimateRoute(city CityCode, req *GetQuotationRequest) (*RouteEstimate, error) {
+	if len(req.Stops) < 2 {
+		return nil, errInsufficientStops
+	}
+	legs, err := legDistances(req.Stops)
+	if err != nil {
+		return nil, err
+	}
+	est := &RouteEstimate{Legs: legs}
+	for _, leg := range legs {
+		est.Distance += leg
+	}
+	factor, ok := RoadDistanceFactors[city]
+	if !ok {
+		factor = defaultRoadDistanceFactor
+	}
+	speed, ok := AverageSpeeds[req.ServiceType]
+	if !ok {
+		speed = defaultAverageSpeed
+	}
+	est.RoadDistance = est.Distance * factor
+	est.Duration = time.Duration(est.RoadDistance / (speed * 1000 / 3600) * float64(time.Second))
+	return est, nil
+}
+
+func legDistances(stops []Waypoint) ([]float64, error) {
+	points, err := stopCoordinates(stops)
+	if err != nil {
+		return nil, err
+	}
+	var legs []float64
+	for i := 1; i < len(points); i++ {
+		legs = append(legs, points[i-1].DistanceTo(points[i]))
+	}
+	return legs, nil
+}
+
+func stopCoordinates(stops []Waypoint) ([]LatLng, error) {
+	points := make([]LatLng, len(stops))
+	for i, stop := range stops {
+		pt, err := stop.Location.LatLng()
+		if err != nil {
+			return nil, fmt.Errorf("stops[%d]: %w", i, err)
+		}
+		points[i] = pt
+	}
+	return points, nil
+}
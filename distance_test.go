@@ -0,0 +1,110 @@
+package lalamove
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDistanceTo(t *testing.T) {
+	tests := []struct {
+		name string
+		p, q LatLng
+		want float64
+	}{
+		{"same point", LatLng{14.5547, 121.0244}, LatLng{14.5547, 121.0244}, 0},
+		{"one degree along the equator", LatLng{0, 0}, LatLng{0, 1}, 2 * math.Pi * earthRadius / 360},
+		{"equator to pole", LatLng{0, 45}, LatLng{90, 0}, math.Pi / 2 * earthRadius},
+		{"antipodes", LatLng{0, 0}, LatLng{0, 180}, math.Pi * earthRadius},
+		{"across the antimeridian", LatLng{0, 179.5}, LatLng{0, -179.5}, 2 * math.Pi * earthRadius / 360},
+	}
+	for _, tt := range tests {
+		if got := tt.p.DistanceTo(tt.q); math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("DistanceTo(%s) = %f, want %f", tt.name, got, tt.want)
+		}
+		if got, back := tt.p.DistanceTo(tt.q), tt.q.DistanceTo(tt.p); math.Abs(got-back) > 1e-6 {
+			t.Errorf("DistanceTo(%s) is not symmetric: %f and %f", tt.name, got, back)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	// Makati to Ortigas is about 7 km as the crow flies.
+	got, err := Distance(Location{Lat: "14.5547", Lng: "121.0244"}, Location{Lat: "14.5764", Lng: "121.0851"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got < 6900 || got > 7000 {
+		t.Errorf("Distance() = %f, want about 6950 m", got)
+	}
+	if _, err := Distance(Location{Lat: "14.5547", Lng: "121.0244"}, Location{Lat: "north"}); err == nil {
+		t.Error("Distance() accepted invalid coordinates")
+	}
+}
+
+func TestPathLength(t *testing.T) {
+	stops := []Waypoint{
+		{Location: Location{Lat: "0", Lng: "0"}},
+		{Location: Location{Lat: "0", Lng: "1"}},
+		{Location: Location{Lat: "0", Lng: "0"}},
+	}
+	got, err := PathLength(stops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 4 * math.Pi * earthRadius / 360; math.Abs(got-want) > 1e-6 {
+		t.Errorf("PathLength() = %f, want %f", got, want)
+	}
+	if got, err := PathLength(stops[:1]); err != nil || got != 0 {
+		t.Errorf("PathLength() of a single stop = %f, %v, want 0", got, err)
+	}
+	stops[1].Location.Lng = "east"
+	if _, err := PathLength(stops); err == nil {
+		t.Error("PathLength() accepted invalid coordinates")
+	}
+}
+
+func TestEstimateRoute(t *testing.T) {
+	degree := 2 * math.Pi * earthRadius / 360
+	req := func(serviceType ServiceType, lngs ...string) *GetQuotationRequest {
+		r := &GetQuotationRequest{ServiceType: serviceType}
+		for _, lng := range lngs {
+			r.Stops = append(r.Stops, Waypoint{Location: Location{Lat: "0", Lng: lng}})
+		}
+		return r
+	}
+	tests := []struct {
+		name         string
+		city         CityCode
+		req          *GetQuotationRequest
+		wantLegs     int
+		wantDistance float64
+		wantFactor   float64
+		wantSpeed    float64
+	}{
+		{"city factor and service speed", CityCodeSingaporeSingapore, req(ServiceTypeMotorcycle, "0", "0.1", "0.3"), 2, 0.3 * degree, 1.3, 25},
+		{"default factor", CityCodePhilippinesManila, req(ServiceTypeTruck330, "0", "0.2"), 1, 0.2 * degree, defaultRoadDistanceFactor, 16},
+		{"default speed", CityCodeHongKongHongKong, req("HOVERCRAFT", "0", "0.2"), 1, 0.2 * degree, 1.5, defaultAverageSpeed},
+	}
+	for _, tt := range tests {
+		est, err := EstimateRoute(tt.city, tt.req)
+		if err != nil {
+			t.Fatalf("EstimateRoute(%s) error = %v", tt.name, err)
+		}
+		if len(est.Legs) != tt.wantLegs || math.Abs(est.Distance-tt.wantDistance) > 1e-6 {
+			t.Errorf("EstimateRoute(%s) = %d legs over %f m, want %d over %f m", tt.name, len(est.Legs), est.Distance, tt.wantLegs, tt.wantDistance)
+		}
+		if want := tt.wantDistance * tt.wantFactor; math.Abs(est.RoadDistance-want) > 1e-6 {
+			t.Errorf("EstimateRoute(%s) road distance = %f, want %f", tt.name, est.RoadDistance, want)
+		}
+		want := time.Duration(est.RoadDistance / (tt.wantSpeed / 3.6) * float64(time.Second))
+		if diff := est.Duration - want; diff > time.Millisecond || diff < -time.Millisecond {
+			t.Errorf("EstimateRoute(%s) duration = %s, want %s", tt.name, est.Duration, want)
+		}
+	}
+
+	if _, err := EstimateRoute(CityCodePhilippinesManila, req(ServiceTypeMotorcycle, "0")); !errors.Is(err, errInsufficientStops) {
+		t.Errorf("EstimateRoute() of a single stop error = %v, want %v", err, errInsufficientStops)
+	}
+}
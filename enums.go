@@ -0,0 +1,252 @@
+package lalamove
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// EnumDecoding controls how a Client treats values of OrderStatus, ServiceType, SpecialRequest,
+// Locale and CityCode unknown to this package in the responses it decodes.
+type EnumDecoding int
+
+// EnumDecoding enum
+const (
+	// EnumDecodingLenient keeps unknown values as they are. This is the default.
+	EnumDecodingLenient EnumDecoding = iota
+	// EnumDecodingStrict fails the request with an UnknownEnumError. Methods which change an order
+	// return the decoded response along with the error, as the change was made.
+	EnumDecodingStrict
+)
+
+// UnknownEnumError reports a value of an enum type unknown to this package.
+type UnknownEnumError struct {
+	// Type is the name of the enum type, e.g. "OrderStatus".
+	Type  string
+	Value string
+}
+
+func (e *UnknownEnumError) Error() string {
+	return fmt.Sprintf("unknown %s %q", e.Type, e.Value)
+}
+
+// WithEnumDecoding configures how a Lalamove API client treats unknown enum values in responses.
+func WithEnumDecoding(mode EnumDecoding) ClientOption {
+	return func(c *Client) error {
+		c.enumDecoding = mode
+		return nil
+	}
+}
+
+// WithUnknownEnumHandler configures a Lalamove API client with a callback invoked with the type
+// name and value of every unknown enum value in a response, in either mode. Use it to alert when
+// the API evolves.
+func WithUnknownEnumHandler(fn func(typ, value string)) ClientOption {
+	return func(c *Client) error {
+		c.onUnknownEnum = fn
+		return nil
+	}
+}
+
+// checkEnums applies the enum decoding mode of the client to a decoded response.
+func (c *Client) checkEnums(apiResp interface{}) error {
+	return checkEnums(apiResp, c.enumDecoding, c.onUnknownEnum)
+}
+
+// checkEnums passes every unknown enum value found in v to onUnknown, if set, and in strict mode
+// returns the first one.
+func checkEnums(v interface{}, mode EnumDecoding, onUnknown func(typ, value string)) error {
+	if v == nil || (mode == EnumDecodingLenient && onUnknown == nil) {
+		return nil
+	}
+	unknown := unknownEnums(reflect.ValueOf(v), nil)
+	if onUnknown != nil {
+		for _, e := range unknown {
+			onUnknown(e.Type, e.Value)
+		}
+	}
+	if mode == EnumDecodingStrict && len(unknown) > 0 {
+		return unknown[0]
+	}
+	return nil
+}
+
+// isUnknownEnum reports whether the error is an UnknownEnumError.
+func isUnknownEnum(err error) bool {
+	var unknown *UnknownEnumError
+	return errors.As(err, &unknown)
+}
+
+// enumTypes are the enum types checked in decoded responses.
+var enumTypes = map[reflect.Type]bool{
+	reflect.TypeOf(OrderStatus("")):    true,
+	reflect.TypeOf(ServiceType("")):    true,
+	reflect.TypeOf(SpecialRequest("")): true,
+	reflect.TypeOf(Locale("")):         true,
+	reflect.TypeOf(CityCode("")):       true,
+}
+
+// rawJSONType is skipped by unknownEnums, as it holds JSON not decoded into enum types.
+var rawJSONType = reflect.TypeOf(json.RawMessage(nil))
+
+// unknownEnums appends the non-empty values of enum types unknown to this package found in v,
+// including map keys.
+func unknownEnums(v reflect.Value, found []*UnknownEnumError) []*UnknownEnumError {
+	if v.Type() == rawJSONType {
+		return found
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			found = unknownEnums(v.Elem(), found)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				found = unknownEnums(v.Field(i), found)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			found = unknownEnums(v.Index(i), found)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			found = unknownEnums(iter.Key(), found)
+			found = unknownEnums(iter.Value(), found)
+		}
+	case reflect.String:
+		if enumTypes[v.Type()] && v.Len() > 0 {
+			if err := v.Interface().(interface{ Valid() error }).Valid(); err != nil {
+				found = append(found, err.(*UnknownEnumError))
+			}
+		}
+	}
+	return found
+}
+
+func knownSet(values ...string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+var knownOrderStatuses = knownSet(
+	string(OrderStatusAssigningDriver), string(OrderStatusOngoing), string(OrderStatusPickedUp),
+	string(OrderStatusCompleted), string(OrderStatusCanceled), string(OrderStatusRejected), string(OrderStatusExpired),
+)
+
+// IsKnown reports whether the status is one of the OrderStatus constants.
+func (s OrderStatus) IsKnown() bool {
+	return knownOrderStatuses[string(s)]
+}
+
+// Valid returns an UnknownEnumError if the status is not one of the OrderStatus constants.
+func (s OrderStatus) Valid() error {
+	if !s.IsKnown() {
+		return &UnknownEnumError{Type: "OrderStatus", Value: string(s)}
+	}
+	return nil
+}
+
+var knownServiceTypes = knownSet(
+	string(ServiceTypeCar), string(ServiceTypeLalago), string(ServiceTypeLalapro), string(ServiceTypeMinivan),
+	string(ServiceTypeMotorcycle), string(ServiceTypeMPV), string(ServiceTypeTataAce7), string(ServiceTypeTataAce8),
+	string(ServiceTypeThreeWheeler), string(ServiceTypeTruck175), string(ServiceTypeTruck330),
+	string(ServiceTypeTruck550), string(ServiceTypeUV), string(ServiceTypeVan), string(ServiceType4x4),
+)
+
+// IsKnown reports whether the service type is one of the ServiceType constants.
+func (t ServiceType) IsKnown() bool {
+	return knownServiceTypes[string(t)]
+}
+
+// Valid returns an UnknownEnumError if the service type is not one of the ServiceType constants.
+func (t ServiceType) Valid() error {
+	if !t.IsKnown() {
+		return &UnknownEnumError{Type: "ServiceType", Value: string(t)}
+	}
+	return nil
+}
+
+var knownSpecialRequests = knownSet(
+	string(SpecialRequest1HelperTier1), string(SpecialRequest1HelperTier2), string(SpecialRequest1HelperTier3),
+	string(SpecialRequestAddAssistantTier1), string(SpecialRequestAddAssistantTier2), string(SpecialRequestAddAssistantTier3),
+	string(SpecialRequestInsulatedBag), string(SpecialRequestUVVan), string(SpecialRequestLalabag),
+	string(SpecialRequestLalabagBig), string(SpecialRequestDoor2Door), string(SpecialRequestDoor2DoorDriver),
+	string(SpecialRequestDoor2DoorTruck330), string(SpecialRequestDoor2DoorTruck550),
+	string(SpecialRequestDoor2Door1HelperTruck175), string(SpecialRequestDoor2Door1HelperTruck330),
+	string(SpecialRequestDoor2Door1HelperTruck550), string(SpecialRequestDoor2Door2HelperTruck330),
+	string(SpecialRequestDoor2Door2HelperTruck550), string(SpecialRequestCOD), string(SpecialRequestPurchaseService),
+	string(SpecialRequestPurchaseServiceTier2), string(SpecialRequestExtraHelper), string(SpecialRequestExtraHelperTruck175),
+	string(SpecialRequestRoundtripMotorcycle), string(SpecialRequestRoundtripTruck175), string(SpecialRequestRoundtripTruck330),
+	string(SpecialRequestQueueingMotorcycle), string(SpecialRequestReturnTrip), string(SpecialRequestReturnTripLorry),
+	string(SpecialRequestLoadingService), string(SpecialRequestFoodService), string(SpecialRequestDriverCarries),
+	string(SpecialRequest1Assistant1To2Drops), string(SpecialRequest1Assistant3To4Drops), string(SpecialRequest1AssistantPlusDrops),
+	string(SpecialRequestRestricted), string(SpecialRequestMovingDriver), string(SpecialRequestMovingDriver1Helper),
+	string(SpecialRequestMovingDriver2Helper), string(SpecialRequestMovingDriver1HelperVan),
+	string(SpecialRequestMovingDriver2HelperVan), string(SpecialRequestTailgate), string(SpecialRequestCovered),
+	string(SpecialRequestHelpBuy), string(SpecialRequestGroundFloor1Way), string(SpecialRequestGroundFloor1Way2),
+	string(SpecialRequestUpstairDownstair1Way), string(SpecialRequestUpstairDownstair1Way2),
+)
+
+// IsKnown reports whether the special request is one of the SpecialRequest constants.
+func (r SpecialRequest) IsKnown() bool {
+	return knownSpecialRequests[string(r)]
+}
+
+// Valid returns an UnknownEnumError if the special request is not one of the SpecialRequest constants.
+func (r SpecialRequest) Valid() error {
+	if !r.IsKnown() {
+		return &UnknownEnumError{Type: "SpecialRequest", Value: string(r)}
+	}
+	return nil
+}
+
+var knownLocales = knownSet(
+	string(LocaleBrasilEN), string(LocaleBrasilPT), string(LocaleHongKongEN), string(LocaleHongKongZH),
+	string(LocaleIndiaEN), string(LocaleIndiaHI), string(LocaleIndiaKN), string(LocaleIndiaMR),
+	string(LocaleIndonesiaEN), string(LocaleIndonesiaID), string(LocaleMalaysiaEN), string(LocaleMalaysiaMS),
+	string(LocaleMexicoEN), string(LocaleMexicoMX), string(LocalePhilippinesEN), string(LocaleSingaporeEN),
+	string(LocaleTaiwanZH), string(LocaleThailandEN), string(LocaleThailandTH), string(LocaleVietnamEN),
+	string(LocaleVietnamVI),
+)
+
+// IsKnown reports whether the locale is one of the Locale constants.
+func (l Locale) IsKnown() bool {
+	return knownLocales[string(l)]
+}
+
+// Valid returns an UnknownEnumError if the locale is not one of the Locale constants.
+func (l Locale) Valid() error {
+	if !l.IsKnown() {
+		return &UnknownEnumError{Type: "Locale", Value: string(l)}
+	}
+	return nil
+}
+
+// IsKnown reports whether the city is one of the CityCode constants.
+func (c CityCode) IsKnown() bool {
+	return c.GetCountry().Code != ""
+}
+
+// Valid returns an UnknownEnumError if the city is not one of the CityCode constants.
+func (c CityCode) Valid() error {
+	if !c.IsKnown() {
+		return &UnknownEnumError{Type: "CityCode", Value: string(c)}
+	}
+	return nil
+}
+
+// IsKnown reports whether the reason is one of the ChangeDriverReason constants.
+func (r ChangeDriverReason) IsKnown() bool {
+	switch r {
+	case ChangeDriverReasonLate, ChangeDriverReasonAskedChange, ChangeDriverReasonUnresponsive, ChangeDriverReasonRude:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package lalamove
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEnumsValid(t *testing.T) {
+	known := []interface{ Valid() error }{
+		OrderStatusPickedUp, ServiceTypeTruck550, SpecialRequestHelpBuy, LocaleVietnamVI, CityCodeThailandPattaya,
+	}
+	for _, v := range known {
+		if err := v.Valid(); err != nil {
+			t.Errorf("%v.Valid() = %v", v, err)
+		}
+	}
+	unknown := map[string]interface{ Valid() error }{
+		"OrderStatus":    OrderStatus("ARRIVED"),
+		"ServiceType":    ServiceType("HOVERCRAFT"),
+		"SpecialRequest": SpecialRequest("CARRY_PIANO"),
+		"Locale":         Locale("fr_FR"),
+		"CityCode":       CityCode("FR_PAR"),
+	}
+	for typ, v := range unknown {
+		var e *UnknownEnumError
+		if err := v.Valid(); !errors.As(err, &e) || e.Type != typ || e.Value != reflect.ValueOf(v).String() {
+			t.Errorf("%v.Valid() = %v, want an UnknownEnumError of %s", v, err, typ)
+		}
+	}
+	if !ChangeDriverReasonRude.IsKnown() || ChangeDriverReason("BORED").IsKnown() {
+		t.Error("ChangeDriverReason.IsKnown does not match the constants")
+	}
+}
+
+func TestUnknownEnums(t *testing.T) {
+	type nested struct {
+		Status   OrderStatus
+		Services []ServiceType
+		Requests map[SpecialRequest]Money
+		Cities   map[string]*CityCode
+		Raw      json.RawMessage
+		hidden   Locale
+		Empty    Locale
+	}
+	city := CityCode("FR_PAR")
+	v := &nested{
+		Status:   OrderStatusOngoing,
+		Services: []ServiceType{ServiceTypeVan, "HOVERCRAFT"},
+		Requests: map[SpecialRequest]Money{"CARRY_PIANO": {Amount: "10"}},
+		Cities:   map[string]*CityCode{"paris": &city},
+		Raw:      json.RawMessage(`{"status":"ARRIVED"}`),
+		hidden:   "fr_FR",
+	}
+	var got []string
+	for _, e := range unknownEnums(reflect.ValueOf(v), nil) {
+		got = append(got, e.Type+"="+e.Value)
+	}
+	want := []string{"ServiceType=HOVERCRAFT", "SpecialRequest=CARRY_PIANO", "CityCode=FR_PAR"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unknownEnums() = %v, want %v", got, want)
+	}
+}
+
+// enumServer answers every request with a body carrying the unknown status ARRIVED.
+func enumServer() roundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		return stubResponse(req, http.StatusOK, time.Time{}, `{"orderRef":"1001","customerOrderId":"c-1001","status":"ARRIVED","price":{"amount":"163.00","currency":"PHP"}}`), nil
+	}
+}
+
+func TestEnumDecodingLenient(t *testing.T) {
+	var reported []string
+	c := testClient(t, enumServer(), WithUnknownEnumHandler(func(typ, value string) {
+		reported = append(reported, typ+"="+value)
+	}))
+	details, err := c.OrderDetails(context.Background(), CityCodePhilippinesManila, "1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Status != "ARRIVED" {
+		t.Errorf("Status = %s, want the unknown status as received", details.Status)
+	}
+	if want := []string{"OrderStatus=ARRIVED"}; !reflect.DeepEqual(reported, want) {
+		t.Errorf("reported %v, want %v", reported, want)
+	}
+
+	c = testClient(t, enumServer())
+	if _, err := c.OrderDetails(context.Background(), CityCodePhilippinesManila, "1001"); err != nil {
+		t.Errorf("OrderDetails() without a handler = %v", err)
+	}
+}
+
+func TestEnumDecodingStrict(t *testing.T) {
+	var reported int
+	c := testClient(t, enumServer(), WithEnumDecoding(EnumDecodingStrict), WithUnknownEnumHandler(func(typ, value string) {
+		reported++
+	}))
+	ctx := context.Background()
+	var e *UnknownEnumError
+
+	details, err := c.OrderDetails(ctx, CityCodePhilippinesManila, "1001")
+	if !errors.As(err, &e) || e.Type != "OrderStatus" || e.Value != "ARRIVED" || details != nil {
+		t.Errorf("OrderDetails() = %+v, %v, want an UnknownEnumError", details, err)
+	}
+	if reported != 1 {
+		t.Errorf("handler called %d times, want 1 in strict mode too", reported)
+	}
+
+	// The order was changed, so its details are returned along with the error.
+	edited, err := c.EditOrder(ctx, CityCodePhilippinesManila, "1001", &EditOrderRequest{RequesterContact: &Contact{Name: "Juan dela Cruz", Phone: "+639171234567"}})
+	if !errors.As(err, &e) || edited == nil || edited.Status != "ARRIVED" {
+		t.Errorf("EditOrder() = %+v, %v, want the details and an UnknownEnumError", edited, err)
+	}
+
+	// Responses the client does not decode are not checked.
+	if err := c.AddPriorityFee(ctx, CityCodePhilippinesManila, "1001", Money{Amount: "20", Currency: "PHP"}); err != nil {
+		t.Errorf("AddPriorityFee() = %v", err)
+	}
+}
+
+func TestEnumDecodingStrictRecording(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryOrderStore()
+	if err := store.CreateOrder(ctx, testOrderRecord("1001", testNow)); err != nil {
+		t.Fatal(err)
+	}
+	c := NewRecordingClient(testClient(t, enumServer(), WithEnumDecoding(EnumDecodingStrict)), store)
+	edited, err := c.EditOrder(ctx, CityCodePhilippinesManila, "1001", &EditOrderRequest{RequesterContact: &Contact{Name: "Juan dela Cruz", Phone: "+639171234567"}})
+	if !isUnknownEnum(err) || edited == nil {
+		t.Fatalf("EditOrder() = %+v, %v, want the details and an UnknownEnumError", edited, err)
+	}
+	rec, err := store.GetOrder(ctx, "1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := rec.Events[len(rec.Events)-1]; last.Status != "ARRIVED" {
+		t.Errorf("last event = %+v, want the edited order recorded", last)
+	}
+}
+
+func TestFakeServerRejectsUnknownEnums(t *testing.T) {
+	now := testNow
+	c := fakeServerClient(t, testScenario(), &now)
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		modify func(*GetQuotationRequest)
+		want   error
+	}{
+		{"service type", func(r *GetQuotationRequest) { r.ServiceType = "HOVERCRAFT" }, errInvalidServiceType},
+		{"special request", func(r *GetQuotationRequest) { r.SpecialRequests = &[]SpecialRequest{"CARRY_PIANO"} }, errInvalidSpecialRequest},
+		{"locale", func(r *GetQuotationRequest) {
+			r.Stops[1].Addresses = AddressTranslations{"fr_FR": {DisplayString: "Pasig", Country: "PH_MNL"}}
+		}, errInvalidLocale},
+	}
+	for _, tt := range tests {
+		req := testQuotation()
+		tt.modify(req)
+		if _, err := c.GetQuotation(ctx, CityCodePhilippinesManila, req); !errors.Is(err, tt.want) {
+			t.Errorf("GetQuotation() with an unknown %s = %v, want %v", tt.name, err, tt.want)
+		}
+		if _, err := c.PlaceOrder(ctx, CityCodePhilippinesManila, &PlaceOrderRequest{QuotedPrice: Price{Amount: "163.00", Currency: "PHP"}, GetQuotationRequest: *req}); !errors.Is(err, tt.want) {
+			t.Errorf("PlaceOrder() with an unknown %s = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestWebhookDecodeOrder(t *testing.T) {
+	ev := &WebhookEvent{Data: json.RawMessage(`{"order":{"orderId":"1001","status":"ARRIVED"}}`)}
+	order, err := ev.Order()
+	if err != nil || order.Status != "ARRIVED" {
+		t.Errorf("Order() = %+v, %v, want the unknown status as received", order, err)
+	}
+	var reported []string
+	order, err = ev.DecodeOrder(EnumDecodingStrict, func(typ, value string) { reported = append(reported, value) })
+	if !isUnknownEnum(err) || order == nil || order.OrderID != "1001" {
+		t.Errorf("DecodeOrder(strict) = %+v, %v, want the order and an UnknownEnumError", order, err)
+	}
+	if !reflect.DeepEqual(reported, []string{"ARRIVED"}) {
+		t.Errorf("reported %v, want [ARRIVED]", reported)
+	}
+	ev.Data = json.RawMessage(`{"order":`)
+	if _, err := ev.DecodeOrder(EnumDecodingStrict, nil); err == nil || isUnknownEnum(err) {
+		t.Errorf("DecodeOrder() of truncated data = %v, want a syntax error", err)
+	}
+}
+
+func TestWebhookProcessorStrictDiscardsUnknownStatuses(t *testing.T) {
+	var discarded []error
+	var transitions []Transition
+	p := NewWebhookProcessor(WebhookProcessorConfig{
+		EnumDecoding: EnumDecodingStrict,
+		OnDiscard:    func(ev *WebhookEvent, err error) { discarded = append(discarded, err) },
+		OnTransition: func(t Transition) { transitions = append(transitions, t) },
+	})
+	defer p.Close()
+	ev := &WebhookEvent{EventID: "e1", EventType: WebhookEventOrderStatusChanged, Data: json.RawMessage(`{"order":{"orderId":"1001","status":"ARRIVED"}}`)}
+	if err := p.Process(context.Background(), ev); err != nil {
+		t.Fatalf("Process() = %v, want the event acknowledged", err)
+	}
+	p.Flush()
+	if len(discarded) != 1 || !isUnknownEnum(discarded[0]) || len(transitions) != 0 {
+		t.Errorf("discarded %v with transitions %v, want the event discarded as unknown", discarded, transitions)
+	}
+}
+
+func TestSimulatorStrictRefusesUnknownStatuses(t *testing.T) {
+	sim := &WebhookSimulator{URL: "http://localhost:1/webhook", HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Error("sent an event")
+		return stubResponse(req, http.StatusOK, time.Time{}, ""), nil
+	})}}
+	_, err := sim.Simulate(context.Background(), "1001", []OrderStatus{OrderStatusAssigningDriver, "ARRIVED"}, SimulationOptions{EnumDecoding: EnumDecodingStrict})
+	if !isUnknownEnum(err) {
+		t.Errorf("Simulate() = %v, want an UnknownEnumError", err)
+	}
+}
@@ -0,0 +1,92 @@
+package lalamove
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Environment describes a Lalamove API deployment the client can talk to.
+type Environment struct {
+	// Name is a human readable name of the environment, e.g. "sandbox".
+	Name string
+	// BaseURL is the host of the environment. The v2 APIs serve every market from the same host and
+	// route requests by the X-LLM-Country header.
+	BaseURL string
+	// KeyPrefix is the prefix of API keys issued for this environment.
+	KeyPrefix string
+	// SecretPrefix is the prefix of secrets issued for this environment.
+	SecretPrefix string
+}
+
+// Environment enum
+var (
+	// Sandbox is the Lalamove test environment. Orders placed here are never fulfilled.
+	Sandbox = Environment{
+		Name:         "sandbox",
+		BaseURL:      "https://sandbox-rest.lalamove.com",
+		KeyPrefix:    "pk_test_",
+		SecretPrefix: "sk_test_",
+	}
+	// Production is the live Lalamove environment.
+	Production = Environment{
+		Name:         "production",
+		BaseURL:      "https://rest.lalamove.com",
+		KeyPrefix:    "pk_prod_",
+		SecretPrefix: "sk_prod_",
+	}
+)
+
+// allEnvironments list the presets used to detect which environment a credential belongs to.
+var allEnvironments = []Environment{Sandbox, Production}
+
+// checkCredentials refuses credentials that were issued for a different environment.
+func (e Environment) checkCredentials(apiKey, secret string) error {
+	for _, other := range allEnvironments {
+		if other.Name == e.Name {
+			continue
+		}
+		if hasCredentialPrefix(apiKey, other.KeyPrefix) || hasCredentialPrefix(secret, other.SecretPrefix) {
+			return errEnvironmentMismatch
+		}
+	}
+	return nil
+}
+
+// environmentForBaseURL returns the environment served from the host of baseURL: one of the
+// presets, or else the configured environment. It returns false for hosts of neither.
+func environmentForBaseURL(baseURL string, configured *Environment) (Environment, bool) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return Environment{}, false
+	}
+	candidates := allEnvironments
+	if configured != nil {
+		candidates = append(candidates[:len(candidates):len(candidates)], *configured)
+	}
+	for _, env := range candidates {
+		if envURL, err := url.Parse(env.BaseURL); err == nil && strings.EqualFold(envURL.Host, u.Host) {
+			return env, true
+		}
+	}
+	return Environment{}, false
+}
+
+// hasCredentialPrefix reports whether the credential carries the given prefix.
+// Credentials without a recognized prefix are never attributed to an environment.
+func hasCredentialPrefix(credential, prefix string) bool {
+	return prefix != "" && strings.HasPrefix(credential, prefix)
+}
+
+// WithEnvironment configures a Lalamove API client to use one of the Environment presets.
+// NewClient fails if the base URL of env cannot be parsed.
+func WithEnvironment(env Environment) ClientOption {
+	return func(c *Client) error {
+		if _, err := url.ParseRequestURI(env.BaseURL); err != nil {
+			return fmt.Errorf("environment %s: %w", env.Name, err)
+		}
+		c.environment = &env
+		c.baseURL = env.BaseURL
+		return nil
+	}
+}
@@ -0,0 +1,47 @@
+package lalamove
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestEnvironmentCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []ClientOption
+		apiKey  string
+		secret  string
+		wantURL string
+		wantErr error
+	}{
+		{"sandbox credentials in sandbox", []ClientOption{WithEnvironment(Sandbox)}, "pk_test_abc", "sk_test_abc", Sandbox.BaseURL, nil},
+		{"production credentials in production", []ClientOption{WithEnvironment(Production)}, "pk_prod_abc", "sk_prod_abc", Production.BaseURL, nil},
+		{"unprefixed credentials", []ClientOption{WithEnvironment(Production)}, "abc", "def", Production.BaseURL, nil},
+		{"sandbox key in production", []ClientOption{WithEnvironment(Production)}, "pk_test_abc", "sk_prod_abc", "", errEnvironmentMismatch},
+		{"production secret in sandbox", []ClientOption{WithEnvironment(Sandbox)}, "pk_test_abc", "sk_prod_abc", "", errEnvironmentMismatch},
+		{"sandbox key at the production host", []ClientOption{WithBaseURL("https://rest.lalamove.com")}, "pk_test_abc", "sk_test_abc", "", errEnvironmentMismatch},
+		{"production key at the sandbox host", []ClientOption{WithBaseURL("https://SANDBOX-REST.lalamove.com/")}, "pk_prod_abc", "sk_prod_abc", "", errEnvironmentMismatch},
+		{"base URL overriding the environment", []ClientOption{WithEnvironment(Sandbox), WithBaseURL(Production.BaseURL)}, "pk_test_abc", "sk_test_abc", "", errEnvironmentMismatch},
+		{"custom base URL", []ClientOption{WithBaseURL("http://localhost:8081")}, "pk_prod_abc", "sk_test_abc", "http://localhost:8081", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(append(tt.options, WithAPIKey(tt.apiKey), WithSecret(tt.secret))...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewClient() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && c.baseURL != tt.wantURL {
+				t.Errorf("base URL = %s, want %s", c.baseURL, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestEnvironmentInvalidBaseURL(t *testing.T) {
+	_, err := NewClient(WithEnvironment(Environment{Name: "staging", BaseURL: "not a url"}), WithAPIKey("abc"), WithSecret("def"))
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		t.Fatalf("NewClient() error = %v, want a URL parse error", err)
+	}
+}
@@ -1,10 +1,15 @@
 package lalamove
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
-	errCredentialsMissing = errors.New("API Key credentials missing")
-	errBaseURLMissing     = errors.New("base URL missing")
+	errCredentialsMissing  = errors.New("API Key credentials missing")
+	errBaseURLMissing      = errors.New("base URL missing")
+	errEnvironmentMismatch = errors.New("credentials do not belong to the configured environment")
+	errClockSkew           = errors.New("signing timestamp rejected due to clock skew")
 )
 
 var (
@@ -52,6 +57,22 @@ var (
 	errUnauthorized = errors.New("ERR_UNAUTHORIZED")
 )
 
+// HTTPError is returned for responses with an HTTP error status. It wraps the error reported by the
+// response, or ERR_UNKNOWN if none is recognized, so that errors.Is matches it.
+type HTTPError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d: %v", e.StatusCode, e.Err)
+}
+
+// Unwrap returns the error reported by the response.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
 func wrapAPIError(errResp *ErrorResponse) error {
 	switch errResp.Error {
 	case "ERR_INVALID_COUNTRY":
@@ -0,0 +1,85 @@
+package lalamove
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PriorityFeeEscalation configures EscalatePriorityFee.
+type PriorityFeeEscalation struct {
+	// Step is the priority fee added at each escalation.
+	Step Money
+	// Cap is the maximum total priority fee added by the escalation.
+	Cap Money
+	// Interval is the time given to drivers to accept the order between escalations.
+	Interval time.Duration
+}
+
+// Validate checks the escalation can make progress: Step and Interval must be positive and Cap must
+// allow at least one Step.
+func (e *PriorityFeeEscalation) Validate() error {
+	if e.Interval <= 0 {
+		return fmt.Errorf("interval: %w", errInvalidParams)
+	}
+	if cmp, err := e.Step.Cmp(Money{Amount: "0", Currency: e.Step.Currency}); err != nil {
+		return fmt.Errorf("step: %w", err)
+	} else if cmp <= 0 {
+		return fmt.Errorf("step: %w", errInvalidParams)
+	}
+	if cmp, err := e.Cap.Cmp(e.Step); err != nil {
+		return fmt.Errorf("cap: %w", err)
+	} else if cmp < 0 {
+		return fmt.Errorf("cap below step: %w", errInvalidParams)
+	}
+	return nil
+}
+
+// EscalationResult is the outcome of EscalatePriorityFee.
+type EscalationResult struct {
+	// Added is the total priority fee added.
+	Added Money
+	// Details are the order details retrieved last.
+	Details *OrderDetailsResponse
+}
+
+// EscalatePriorityFee raises the priority fee of an order stuck in ASSIGNING_DRIVER by Step every
+// Interval, until the order leaves ASSIGNING_DRIVER or another step would exceed Cap. It returns when
+// either happens; check the status in the result to tell them apart. The escalation is validated
+// before any request is made.
+func (c *Client) EscalatePriorityFee(ctx context.Context, city CityCode, orderID string, e PriorityFeeEscalation) (*EscalationResult, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	result := &EscalationResult{Added: Money{Amount: "0", Currency: e.Step.Currency}}
+	for {
+		details, err := c.OrderDetails(ctx, city, orderID)
+		if err != nil {
+			return result, err
+		}
+		result.Details = details
+		if details.Status != OrderStatusAssigningDriver {
+			return result, nil
+		}
+		next, err := result.Added.Add(e.Step)
+		if err != nil {
+			return result, err
+		}
+		if cmp, err := next.Cmp(e.Cap); err != nil {
+			return result, err
+		} else if cmp > 0 {
+			return result, nil
+		}
+		if err := c.AddPriorityFee(ctx, city, orderID, e.Step); err != nil {
+			return result, err
+		}
+		result.Added = next
+		t := time.NewTimer(e.Interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return result, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPriorityFeeEscalationValidate(t *testing.T) {
+	php := func(amount string) Money { return Money{Amount: amount, Currency: "PHP"} }
+	tests := []struct {
+		name       string
+		escalation PriorityFeeEscalation
+		want       error
+	}{
+		{"valid", PriorityFeeEscalation{Step: php("10"), Cap: php("50"), Interval: time.Minute}, nil},
+		{"cap equal to step", PriorityFeeEscalation{Step: php("10"), Cap: php("10"), Interval: time.Minute}, nil},
+		{"no interval", PriorityFeeEscalation{Step: php("10"), Cap: php("50")}, errInvalidParams},
+		{"zero step", PriorityFeeEscalation{Step: php("0"), Cap: php("50"), Interval: time.Minute}, errInvalidParams},
+		{"negative step", PriorityFeeEscalation{Step: php("-10"), Cap: php("50"), Interval: time.Minute}, errInvalidParams},
+		{"cap below step", PriorityFeeEscalation{Step: php("10"), Cap: php("5"), Interval: time.Minute}, errInvalidParams},
+		{"mixed currencies", PriorityFeeEscalation{Step: php("10"), Cap: Money{Amount: "50", Currency: "SGD"}, Interval: time.Minute}, errInvalidCurrency},
+	}
+	for _, tt := range tests {
+		err := tt.escalation.Validate()
+		if tt.want == nil && err != nil {
+			t.Errorf("Validate(%s) = %v, want nil", tt.name, err)
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("Validate(%s) = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestEscalatePriorityFeeInvalid(t *testing.T) {
+	c := testClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+		return nil, errors.New("unexpected request")
+	}))
+	_, err := c.EscalatePriorityFee(context.Background(), CityCodePhilippinesManila, "1001", PriorityFeeEscalation{
+		Step: Money{Amount: "10", Currency: "PHP"},
+		Cap:  Money{Amount: "5", Currency: "PHP"},
+	})
+	if !errors.Is(err, errInvalidParams) {
+		t.Errorf("error = %v, want %v", err, errInvalidParams)
+	}
+}
@@ -0,0 +1,440 @@
+package lalamove
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twinj/uuid"
+)
+
+// fakeEndpoints are the endpoints served by FakeServer, named after the Client methods calling them.
+var fakeEndpoints = map[string]bool{
+	"GetQuotation":   true,
+	"PlaceOrder":     true,
+	"OrderDetails":   true,
+	"EditOrder":      true,
+	"CancelOrder":    true,
+	"AddPriorityFee": true,
+	"DriverDetails":  true,
+	"ChangeDriver":   true,
+	"DriverLocation": true,
+	"GetCityInfo":    true,
+	"SetWebhook":     true,
+	"GetWebhook":     true,
+}
+
+// FakeServer is a http.Handler standing in for the Lalamove APIs, answering as scripted by a
+// Scenario. Serve it with net/http/httptest or http.ListenAndServe and point a Client at it
+// WithBaseURL.
+type FakeServer struct {
+	// Now is the source of the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	scenario  *Scenario
+	orderID   string
+	driverID  string
+	interval  time.Duration
+	route     []LatLng
+	routeErr  error
+	mu        sync.Mutex
+	startedAt time.Time
+	placed    int
+	orders    map[string]*fakeOrder
+	webhooks  map[string]string
+}
+
+// fakeOrder is an order placed on a FakeServer.
+type fakeOrder struct {
+	createdAt time.Time
+	// timelineAt is when the timeline of the order started, reset when the driver is changed.
+	timelineAt time.Time
+	canceledAt time.Time
+	price      Price
+	stops      []Waypoint
+}
+
+// fakeOrderState is the state of a fakeOrder at a given time.
+type fakeOrderState struct {
+	status      OrderStatus
+	assignedAt  time.Time
+	completedAt time.Time
+}
+
+func (s fakeOrderState) hasDriver() bool {
+	return s.status == OrderStatusOngoing || s.status == OrderStatusPickedUp || s.status == OrderStatusCompleted
+}
+
+// NewFakeServer constructs a FakeServer playing the scenario, which should have been validated.
+func NewFakeServer(s *Scenario) *FakeServer {
+	f := &FakeServer{
+		Now:      time.Now,
+		scenario: s,
+		orderID:  s.Order.OrderID,
+		driverID: s.Driver.ID,
+		interval: time.Duration(s.Driver.Interval),
+		orders:   map[string]*fakeOrder{},
+		webhooks: map[string]string{},
+	}
+	if f.orderID == "" {
+		f.orderID = "fake-order"
+	}
+	if f.driverID == "" {
+		f.driverID = "fake-driver"
+	}
+	if f.interval <= 0 {
+		f.interval = defaultRouteInterval
+	}
+	f.route, f.routeErr = s.Driver.route()
+	return f
+}
+
+// ServeHTTP implements http.Handler.
+func (f *FakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest, "ERR_INVALID_PARAMS")
+		return
+	}
+	endpoint, orderID, driverID := routeFake(r.Method, r.URL.Path)
+	if endpoint == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if !f.authorized(r, body) {
+		writeFakeError(w, http.StatusUnauthorized, "ERR_UNAUTHORIZED")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.Now()
+	if f.startedAt.IsZero() {
+		f.startedAt = now
+	}
+	elapsed := now.Sub(f.startedAt)
+	var order *fakeOrder
+	if orderID != "" {
+		order = f.orders[orderID]
+		if order == nil {
+			writeFakeError(w, http.StatusNotFound, "ERR_ORDER_NOT_FOUND")
+			return
+		}
+		elapsed = now.Sub(order.createdAt)
+	}
+	for _, failure := range f.scenario.Failures {
+		if failure.Endpoint == endpoint && failure.active(elapsed) {
+			status := failure.StatusCode
+			if status == 0 {
+				status = http.StatusForbidden
+			}
+			writeFakeError(w, status, failure.Error)
+			return
+		}
+	}
+
+	switch endpoint {
+	case "GetQuotation":
+		req := &GetQuotationRequest{}
+		if err := json.Unmarshal(body, req); err != nil {
+			writeFakeError(w, http.StatusBadRequest, "ERR_INVALID_PARAMS")
+			return
+		}
+		if code := unknownEnumCode(req); code != "" {
+			writeFakeError(w, http.StatusConflict, code)
+			return
+		}
+		writeFakeJSON(w, f.scenario.Quotation)
+	case "PlaceOrder":
+		f.placeOrder(w, body, now)
+	case "OrderDetails":
+		writeFakeJSON(w, f.details(order, now))
+	case "EditOrder":
+		req := &EditOrderRequest{}
+		if err := json.Unmarshal(body, req); err != nil {
+			writeFakeError(w, http.StatusBadRequest, "ERR_INVALID_PARAMS")
+			return
+		}
+		if code := unknownEnumCode(req); code != "" {
+			writeFakeError(w, http.StatusConflict, code)
+			return
+		}
+		if status := f.state(order, now).status; status != OrderStatusAssigningDriver && status != OrderStatusOngoing {
+			writeFakeError(w, http.StatusConflict, "ERR_INVALID_PARAMS")
+			return
+		}
+		if len(req.Stops) > 0 {
+			order.stops = req.Stops
+		}
+		writeFakeJSON(w, f.details(order, now))
+	case "CancelOrder":
+		if !DefaultOrderStateMachine.Cancellable(f.state(order, now).status) {
+			writeFakeError(w, http.StatusConflict, "ERR_CANCELLATION_FORBIDDEN")
+			return
+		}
+		order.canceledAt = now
+		w.WriteHeader(http.StatusOK)
+	case "AddPriorityFee":
+		req := &AddPriorityFeeRequest{}
+		if err := json.Unmarshal(body, req); err != nil {
+			writeFakeError(w, http.StatusBadRequest, "ERR_INVALID_PARAMS")
+			return
+		}
+		if f.state(order, now).status != OrderStatusAssigningDriver {
+			writeFakeError(w, http.StatusConflict, "ERR_INVALID_PARAMS")
+			return
+		}
+		price, err := order.price.Add(req.PriorityFee)
+		if err != nil {
+			writeFakeError(w, http.StatusConflict, "ERR_INVALID_CURRENCY")
+			return
+		}
+		order.price = price
+		w.WriteHeader(http.StatusOK)
+	case "DriverDetails":
+		if driverID != f.driverID || !f.state(order, now).hasDriver() {
+			writeFakeError(w, http.StatusNotFound, "ERR_DRIVER_NOT_FOUND")
+			return
+		}
+		writeFakeJSON(w, f.scenario.Driver.DriverDetailsResponse)
+	case "ChangeDriver":
+		req := &ChangeDriverRequest{}
+		if err := json.Unmarshal(body, req); err != nil || !req.Reason.IsKnown() {
+			writeFakeError(w, http.StatusBadRequest, "ERR_INVALID_PARAMS")
+			return
+		}
+		if driverID != f.driverID || f.state(order, now).status != OrderStatusOngoing {
+			writeFakeError(w, http.StatusConflict, "ERR_INVALID_PARAMS")
+			return
+		}
+		order.timelineAt = now
+		w.WriteHeader(http.StatusOK)
+	case "DriverLocation":
+		f.driverLocation(w, order, driverID, now)
+	case "GetCityInfo":
+		writeFakeJSON(w, GetCityInfoResponse{Cities: f.scenario.Cities})
+	case "SetWebhook":
+		req := &Webhook{}
+		if err := json.Unmarshal(body, req); err != nil || validateWebhookURL(req.URL) != nil {
+			writeFakeError(w, http.StatusBadRequest, "ERR_INVALID_PARAMS")
+			return
+		}
+		f.webhooks[r.Header.Get("X-LLM-Country")] = req.URL
+		w.WriteHeader(http.StatusOK)
+	case "GetWebhook":
+		webhookURL, ok := f.webhooks[r.Header.Get("X-LLM-Country")]
+		if !ok {
+			writeFakeError(w, http.StatusNotFound, "ERR_WEBHOOK_NOT_FOUND")
+			return
+		}
+		writeFakeJSON(w, Webhook{URL: webhookURL})
+	}
+}
+
+// routeFake resolves the endpoint of a request, with the order and driver IDs of its path.
+func routeFake(method, path string) (endpoint, orderID, driverID string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "v2" {
+		return "", "", ""
+	}
+	if len(parts) == 2 {
+		switch {
+		case parts[1] == "quotations" && method == http.MethodPost:
+			return "GetQuotation", "", ""
+		case parts[1] == "orders" && method == http.MethodPost:
+			return "PlaceOrder", "", ""
+		case parts[1] == "cities" && method == http.MethodGet:
+			return "GetCityInfo", "", ""
+		case parts[1] == "webhook" && method == http.MethodPut:
+			return "SetWebhook", "", ""
+		case parts[1] == "webhook" && method == http.MethodGet:
+			return "GetWebhook", "", ""
+		}
+		return "", "", ""
+	}
+	if parts[1] != "orders" {
+		return "", "", ""
+	}
+	orderID = parts[2]
+	switch {
+	case len(parts) == 3 && method == http.MethodGet:
+		return "OrderDetails", orderID, ""
+	case len(parts) == 3 && method == http.MethodPatch:
+		return "EditOrder", orderID, ""
+	case len(parts) == 4 && parts[3] == "cancel" && method == http.MethodPut:
+		return "CancelOrder", orderID, ""
+	case len(parts) == 4 && parts[3] == "priority-fee" && method == http.MethodPost:
+		return "AddPriorityFee", orderID, ""
+	case len(parts) == 5 && parts[3] == "drivers" && method == http.MethodGet:
+		return "DriverDetails", orderID, parts[4]
+	case len(parts) == 5 && parts[3] == "drivers" && method == http.MethodDelete:
+		return "ChangeDriver", orderID, parts[4]
+	case len(parts) == 6 && parts[3] == "drivers" && parts[5] == "location" && method == http.MethodGet:
+		return "DriverLocation", orderID, parts[4]
+	}
+	return "", "", ""
+}
+
+// authorized checks the signature of the request if the scenario has credentials.
+func (f *FakeServer) authorized(r *http.Request, body []byte) bool {
+	if f.scenario.Secret == "" {
+		return true
+	}
+	auth := strings.TrimPrefix(r.Header.Get("Authorization"), "hmac ")
+	parts := strings.SplitN(auth, ":", 3)
+	if len(parts) != 3 || parts[0] != f.scenario.APIKey {
+		return false
+	}
+	timestamp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return false
+	}
+	expected := sign(f.scenario.Secret, timestamp, r.Method, r.URL.Path, body)
+	return hmac.Equal([]byte(expected), []byte(parts[2]))
+}
+
+func (f *FakeServer) placeOrder(w http.ResponseWriter, body []byte, now time.Time) {
+	req := &PlaceOrderRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		writeFakeError(w, http.StatusBadRequest, "ERR_INVALID_PARAMS")
+		return
+	}
+	if code := unknownEnumCode(req); code != "" {
+		writeFakeError(w, http.StatusConflict, code)
+		return
+	}
+	quoted := Price{Amount: f.scenario.Quotation.Amount, Currency: f.scenario.Quotation.Currency}
+	if cmp, err := req.QuotedPrice.Cmp(quoted); err != nil || cmp != 0 {
+		writeFakeError(w, http.StatusConflict, "ERR_PRICE_MISMATCH")
+		return
+	}
+	f.placed++
+	id := f.orderID
+	if f.placed > 1 {
+		id = fmt.Sprintf("%s-%d", f.orderID, f.placed)
+	}
+	f.orders[id] = &fakeOrder{
+		createdAt:  now,
+		timelineAt: now,
+		price:      quoted,
+		stops:      req.Stops,
+	}
+	writeFakeJSON(w, PlaceOrderResponse{OrderID: id, CustomerOrderID: uuid.NewV4().String()})
+}
+
+// state plays the timeline of the order up to the given time.
+func (f *FakeServer) state(o *fakeOrder, now time.Time) fakeOrderState {
+	st := fakeOrderState{status: OrderStatusAssigningDriver}
+	for _, step := range f.scenario.Order.Timeline {
+		at := o.timelineAt.Add(time.Duration(step.After))
+		if at.After(now) || (!o.canceledAt.IsZero() && at.After(o.canceledAt)) {
+			break
+		}
+		if step.Status == OrderStatusOngoing && st.status != OrderStatusOngoing {
+			st.assignedAt = at
+		}
+		if step.Status == OrderStatusCompleted {
+			st.completedAt = at
+		}
+		st.status = step.Status
+	}
+	if !o.canceledAt.IsZero() {
+		st.status = OrderStatusCanceled
+	}
+	return st
+}
+
+func (f *FakeServer) details(o *fakeOrder, now time.Time) *OrderDetailsResponse {
+	st := f.state(o, now)
+	createdAt := o.createdAt
+	resp := &OrderDetailsResponse{
+		Status:    st.status,
+		Price:     o.price,
+		ShareLink: f.scenario.Order.ShareLink,
+		CreatedAt: &createdAt,
+	}
+	if st.hasDriver() {
+		resp.DriverID = f.driverID
+		resp.DriverAssignedAt = &st.assignedAt
+	}
+	if !st.completedAt.IsZero() {
+		resp.CompletedAt = &st.completedAt
+	}
+	for i, stop := range o.stops {
+		status := DeliveryStatusPending
+		if st.status == OrderStatusCompleted && i > 0 {
+			status = DeliveryStatusDelivered
+		}
+		resp.Stops = append(resp.Stops, OrderStop{StopID: strconv.Itoa(i), Location: stop.Location, Status: status})
+	}
+	return resp
+}
+
+// driverLocation answers with the point of the route reached since the driver was assigned. As
+// with the Lalamove APIs, the location is forbidden before a driver is assigned and after completion.
+func (f *FakeServer) driverLocation(w http.ResponseWriter, o *fakeOrder, driverID string, now time.Time) {
+	st := f.state(o, now)
+	if driverID != f.driverID || (st.status != OrderStatusOngoing && st.status != OrderStatusPickedUp) {
+		writeFakeError(w, http.StatusForbidden, "")
+		return
+	}
+	route := f.route
+	if f.routeErr != nil || len(route) == 0 {
+		if len(o.stops) == 0 {
+			writeFakeError(w, http.StatusForbidden, "")
+			return
+		}
+		pickUp, err := o.stops[0].Location.LatLng()
+		if err != nil {
+			writeFakeError(w, http.StatusForbidden, "")
+			return
+		}
+		route = []LatLng{pickUp}
+	}
+	pt := positionAt(route, f.interval, now.Sub(st.assignedAt))
+	writeFakeJSON(w, DriverLocationResponse{
+		Location: Location{
+			Lat: strconv.FormatFloat(pt.Lat, 'f', 6, 64),
+			Lng: strconv.FormatFloat(pt.Lng, 'f', 6, 64),
+		},
+		UpdatedAt: now.UTC(),
+	})
+}
+
+func writeFakeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// unknownEnumCode returns the error code answered for the first enum value of the request unknown
+// to this package, or "" if every value is known.
+func unknownEnumCode(req interface{}) string {
+	unknown := unknownEnums(reflect.ValueOf(req), nil)
+	if len(unknown) == 0 {
+		return ""
+	}
+	switch unknown[0].Type {
+	case "ServiceType":
+		return "ERR_INVALID_SERVICE_TYPE"
+	case "SpecialRequest":
+		return "ERR_INVALID_SPECIAL_REQUEST"
+	case "Locale":
+		return "ERR_INVALID_LOCALE"
+	}
+	return "ERR_INVALID_PARAMS"
+}
+
+func writeFakeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if code != "" {
+		json.NewEncoder(w).Encode(ErrorResponse{Error: code})
+	}
+}
@@ -0,0 +1,258 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// httpStatus returns the status code of a HTTPError, or 0.
+func httpStatus(err error) int {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	return 0
+}
+
+func TestFakeServerTimeline(t *testing.T) {
+	ctx := context.Background()
+	now := testNow
+	c := fakeServerClient(t, testScenario(), &now)
+	placed := placeTestOrder(t, ctx, c)
+	if placed.OrderID != "1001" {
+		t.Errorf("order ID = %s, want 1001", placed.OrderID)
+	}
+	tests := []struct {
+		after  time.Duration
+		status OrderStatus
+	}{
+		{0, OrderStatusAssigningDriver},
+		{time.Minute - time.Nanosecond, OrderStatusAssigningDriver},
+		{time.Minute, OrderStatusOngoing},
+		{30 * time.Minute, OrderStatusPickedUp},
+		{time.Hour, OrderStatusCompleted},
+	}
+	for _, tt := range tests {
+		now = testNow.Add(tt.after)
+		details, err := c.OrderDetails(ctx, CityCodePhilippinesManila, placed.OrderID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.Status != tt.status {
+			t.Errorf("status after %s = %s, want %s", tt.after, details.Status, tt.status)
+		}
+		if hasDriver := details.DriverID == "21712"; hasDriver != (tt.after >= time.Minute) {
+			t.Errorf("driver after %s = %q", tt.after, details.DriverID)
+		}
+		if details.DriverAssignedAt != nil && !details.DriverAssignedAt.Equal(testNow.Add(time.Minute)) {
+			t.Errorf("driver assigned at %s, want %s", details.DriverAssignedAt, testNow.Add(time.Minute))
+		}
+		if completed := details.CompletedAt != nil; completed != (tt.status == OrderStatusCompleted) {
+			t.Errorf("completed at %v with the order %s", details.CompletedAt, details.Status)
+		}
+		if len(details.Stops) != 2 || (details.Stops[1].Status == DeliveryStatusDelivered) != (tt.status == OrderStatusCompleted) {
+			t.Errorf("stops after %s = %+v", tt.after, details.Stops)
+		}
+	}
+
+	// Later orders get a suffixed ID and their own timeline.
+	second := placeTestOrder(t, ctx, c)
+	if second.OrderID != "1001-2" {
+		t.Errorf("second order ID = %s, want 1001-2", second.OrderID)
+	}
+	details, err := c.OrderDetails(ctx, CityCodePhilippinesManila, second.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Status != OrderStatusAssigningDriver {
+		t.Errorf("status of the second order = %s, want %s", details.Status, OrderStatusAssigningDriver)
+	}
+}
+
+func TestFakeServerOrderChanges(t *testing.T) {
+	ctx := context.Background()
+	now := testNow
+	c := fakeServerClient(t, testScenario(), &now)
+
+	t.Run("ChangeDriver restarts the timeline", func(t *testing.T) {
+		placed := placeTestOrder(t, ctx, c)
+		now = now.Add(2 * time.Minute)
+		if err := c.ChangeDriver(ctx, CityCodePhilippinesManila, placed.OrderID, "21712", ChangeDriverReasonRude); err != nil {
+			t.Fatal(err)
+		}
+		details, err := c.OrderDetails(ctx, CityCodePhilippinesManila, placed.OrderID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.Status != OrderStatusAssigningDriver {
+			t.Errorf("status = %s, want %s", details.Status, OrderStatusAssigningDriver)
+		}
+		if err := c.ChangeDriver(ctx, CityCodePhilippinesManila, placed.OrderID, "21712", ChangeDriverReasonRude); !errors.Is(err, errInvalidParams) {
+			t.Errorf("ChangeDriver() without a driver = %v, want %v", err, errInvalidParams)
+		}
+	})
+
+	t.Run("CancelOrder stops the timeline", func(t *testing.T) {
+		placed := placeTestOrder(t, ctx, c)
+		if err := c.CancelOrder(ctx, CityCodePhilippinesManila, placed.OrderID); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(2 * time.Hour)
+		details, err := c.OrderDetails(ctx, CityCodePhilippinesManila, placed.OrderID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.Status != OrderStatusCanceled || details.DriverID != "" {
+			t.Errorf("details = %+v, want the order canceled without a driver", details)
+		}
+	})
+
+	t.Run("CancelOrder once picked up", func(t *testing.T) {
+		placed := placeTestOrder(t, ctx, c)
+		now = now.Add(30 * time.Minute)
+		if err := c.CancelOrder(ctx, CityCodePhilippinesManila, placed.OrderID); !errors.Is(err, errCancellationForbidden) {
+			t.Errorf("error = %v, want %v", err, errCancellationForbidden)
+		}
+	})
+
+	t.Run("PlaceOrder with another price", func(t *testing.T) {
+		_, err := c.PlaceOrder(ctx, CityCodePhilippinesManila, &PlaceOrderRequest{
+			QuotedPrice:         Price{Amount: "150.00", Currency: "PHP"},
+			GetQuotationRequest: *testQuotation(),
+		})
+		if !errors.Is(err, errPriceMismatch) {
+			t.Errorf("error = %v, want %v", err, errPriceMismatch)
+		}
+	})
+
+	t.Run("unknown order", func(t *testing.T) {
+		_, err := c.OrderDetails(ctx, CityCodePhilippinesManila, "0")
+		if httpStatus(err) != http.StatusNotFound {
+			t.Errorf("error = %v, want a 404", err)
+		}
+	})
+}
+
+func TestFakeServerDriverLocation(t *testing.T) {
+	ctx := context.Background()
+	now := testNow
+	s := testScenario()
+	s.Driver.Path = []Location{{Lat: "14.5", Lng: "121.0"}, {Lat: "14.6", Lng: "121.0"}}
+	s.Driver.Interval = ScenarioDuration(10 * time.Second)
+	c := fakeServerClient(t, s, &now)
+	placed := placeTestOrder(t, ctx, c)
+
+	if _, err := c.DriverLocation(ctx, CityCodePhilippinesManila, placed.OrderID, "21712"); httpStatus(err) != http.StatusForbidden {
+		t.Errorf("location before assignment: error = %v, want a 403", err)
+	}
+	now = testNow.Add(time.Minute + 5*time.Second)
+	loc, err := c.DriverLocation(ctx, CityCodePhilippinesManila, placed.OrderID, "21712")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Location != (Location{Lat: "14.550000", Lng: "121.000000"}) || !loc.UpdatedAt.Equal(now) {
+		t.Errorf("location = %+v, want half way along the path at %s", loc, now)
+	}
+	if _, err := c.DriverLocation(ctx, CityCodePhilippinesManila, placed.OrderID, "99999"); httpStatus(err) != http.StatusForbidden {
+		t.Errorf("location of another driver: error = %v, want a 403", err)
+	}
+	if _, err := c.DriverDetails(ctx, CityCodePhilippinesManila, placed.OrderID, "99999"); httpStatus(err) != http.StatusNotFound {
+		t.Errorf("details of another driver: error = %v, want a 404", err)
+	}
+	now = testNow.Add(time.Hour)
+	if _, err := c.DriverLocation(ctx, CityCodePhilippinesManila, placed.OrderID, "21712"); httpStatus(err) != http.StatusForbidden {
+		t.Errorf("location after completion: error = %v, want a 403", err)
+	}
+}
+
+func TestFakeServerFailures(t *testing.T) {
+	ctx := context.Background()
+	now := testNow
+	s := testScenario()
+	s.Failures = []ScenarioFailure{
+		// Relative to the first request served.
+		{Endpoint: "PlaceOrder", Until: ScenarioDuration(time.Minute), StatusCode: http.StatusPaymentRequired, Error: "ERR_INSUFFICIENT_CREDIT"},
+		// Relative to the placement of the order.
+		{Endpoint: "DriverDetails", From: ScenarioDuration(2 * time.Minute), Until: ScenarioDuration(5 * time.Minute), StatusCode: http.StatusInternalServerError},
+		// Never ends, and fails with 403 by default.
+		{Endpoint: "GetCityInfo"},
+	}
+	c := fakeServerClient(t, s, &now)
+	req := testQuotation()
+	if _, err := c.GetQuotation(ctx, CityCodePhilippinesManila, req); err != nil {
+		t.Fatal(err)
+	}
+	order := &PlaceOrderRequest{QuotedPrice: Price{Amount: "163.00", Currency: "PHP"}, GetQuotationRequest: *req}
+	if _, err := c.PlaceOrder(ctx, CityCodePhilippinesManila, order); !errors.Is(err, errInsufficientCredit) || httpStatus(err) != http.StatusPaymentRequired {
+		t.Errorf("PlaceOrder() during the failure = %v, want a 402 %v", err, errInsufficientCredit)
+	}
+	now = testNow.Add(time.Minute)
+	placed, err := c.PlaceOrder(ctx, CityCodePhilippinesManila, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetCityInfo(ctx, CountryCodePhilippines); httpStatus(err) != http.StatusForbidden {
+		t.Errorf("GetCityInfo() = %v, want a 403", err)
+	}
+	for _, tt := range []struct {
+		after time.Duration
+		want  int
+	}{
+		{time.Minute, 0},
+		{2 * time.Minute, http.StatusInternalServerError},
+		{5 * time.Minute, 0},
+	} {
+		now = testNow.Add(time.Minute + tt.after)
+		_, err := c.DriverDetails(ctx, CityCodePhilippinesManila, placed.OrderID, "21712")
+		if httpStatus(err) != tt.want || (tt.want == 0 && err != nil) {
+			t.Errorf("DriverDetails() %s after placement = %v, want status %d", tt.after, err, tt.want)
+		}
+	}
+}
+
+func TestFakeServerRequests(t *testing.T) {
+	s := testScenario()
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	fake := NewFakeServer(s)
+	fake.Now = func() time.Time { return testNow }
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		auth   string
+		want   int
+	}{
+		{"unknown endpoint", http.MethodGet, "/v2/unknown", "", http.StatusNotFound},
+		{"wrong method", http.MethodPut, "/v2/quotations", "", http.StatusNotFound},
+		{"outside the API", http.MethodGet, "/v3/cities", "", http.StatusNotFound},
+		{"unsigned", http.MethodGet, "/v2/cities", "", http.StatusUnauthorized},
+		{"other API key", http.MethodGet, "/v2/cities", "hmac pk_test_other:1767258000000:0000", http.StatusUnauthorized},
+		{"bad signature", http.MethodGet, "/v2/cities", "hmac pk_test_fake:1767258000000:0000", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(""))
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		rec := httptest.NewRecorder()
+		fake.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+
+	// Without credentials in the scenario, requests are not checked.
+	s.APIKey, s.Secret = "", ""
+	rec := httptest.NewRecorder()
+	fake.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v2/cities", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("unsigned request without credentials: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -1,5 +1,11 @@
 package lalamove
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // Country ...
 type Country struct {
 	Name       string
@@ -226,3 +232,109 @@ var AllCountriesByISOCode = map[CountryCode]Country{
 	CountryCodeThailand:    CountryThailand,
 	CountryCodeVietnam:     CountryVietnam,
 }
+
+// LatLng is a coordinate in decimal degrees.
+type LatLng struct {
+	Lat float64
+	Lng float64
+}
+
+// Polygon is a closed ring of coordinates. The last vertex connects back to the first.
+type Polygon []LatLng
+
+// Contains reports whether the point lies inside the polygon, using the even-odd rule.
+func (p Polygon) Contains(pt LatLng) bool {
+	inside := false
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		a, b := p[i], p[j]
+		if (a.Lat > pt.Lat) != (b.Lat > pt.Lat) &&
+			pt.Lng < (b.Lng-a.Lng)*(pt.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// ServiceAreas are coarse outlines of the metropolitan area served in each city. They are meant
+// for pre-flight checks; Lalamove remains the authority on what is serviceable.
+var ServiceAreas = map[CityCode]Polygon{
+	CityCodeBrasilSaoPaulo:      {{-23.20, -47.20}, {-23.20, -46.20}, {-24.00, -46.20}, {-24.00, -47.20}},
+	CityCodeBrasilRioDeJaneiro:  {{-22.60, -43.80}, {-22.60, -43.00}, {-23.10, -43.00}, {-23.10, -43.80}},
+	CityCodeHongKongHongKong:    {{22.57, 113.82}, {22.57, 114.45}, {22.15, 114.45}, {22.15, 113.82}},
+	CityCodeIndiaBengaluru:      {{13.25, 77.35}, {13.25, 77.85}, {12.75, 77.85}, {12.75, 77.35}},
+	CityCodeIndiaMumbai:         {{19.35, 72.75}, {19.35, 73.20}, {18.85, 73.20}, {18.85, 72.75}},
+	CityCodeIndiaDelhi:          {{28.90, 76.85}, {28.90, 77.45}, {28.35, 77.45}, {28.35, 76.85}},
+	CityCodeIndonesiaJakarata:   {{-6.05, 106.45}, {-6.05, 107.20}, {-6.65, 107.20}, {-6.65, 106.45}},
+	CityCodeMalaysiaKualaLumpur: {{3.35, 101.35}, {3.35, 101.85}, {2.75, 101.85}, {2.75, 101.35}},
+	CityCodeMexicoMexico:        {{19.75, -99.40}, {19.75, -98.90}, {19.05, -98.90}, {19.05, -99.40}},
+	CityCodePhilippinesManila:   {{14.85, 120.90}, {14.85, 121.20}, {14.25, 121.20}, {14.25, 120.90}},
+	CityCodePhilippinesCebu:     {{10.50, 123.75}, {10.50, 124.05}, {10.15, 124.05}, {10.15, 123.75}},
+	CityCodeSingaporeSingapore:  {{1.48, 103.60}, {1.48, 104.10}, {1.15, 104.10}, {1.15, 103.60}},
+	CityCodeTaiwanTaipei:        {{25.30, 121.30}, {25.30, 121.70}, {24.90, 121.70}, {24.90, 121.30}},
+	CityCodeThailandBangkok:     {{14.10, 100.25}, {14.10, 100.95}, {13.45, 100.95}, {13.45, 100.25}},
+	CityCodeThailandPattaya:     {{13.05, 100.85}, {13.05, 101.00}, {12.80, 101.00}, {12.80, 100.85}},
+	CityCodeVietnamHoChiMinh:    {{11.15, 106.45}, {11.15, 107.00}, {10.60, 107.00}, {10.60, 106.45}},
+	CityCodeVietnamHanoi:        {{21.25, 105.65}, {21.25, 106.05}, {20.85, 106.05}, {20.85, 105.65}},
+}
+
+// LatLng parses the coordinates of the location.
+func (l Location) LatLng() (LatLng, error) {
+	lat, err := strconv.ParseFloat(strings.TrimSpace(l.Lat), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return LatLng{}, fmt.Errorf("invalid latitude %q", l.Lat)
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(l.Lng), 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return LatLng{}, fmt.Errorf("invalid longitude %q", l.Lng)
+	}
+	return LatLng{Lat: lat, Lng: lng}, nil
+}
+
+// CityForLocation returns the city whose service area contains the location.
+func CityForLocation(l Location) (CityCode, bool) {
+	pt, err := l.LatLng()
+	if err != nil {
+		return "", false
+	}
+	for city, area := range ServiceAreas {
+		if area.Contains(pt) {
+			return city, true
+		}
+	}
+	return "", false
+}
+
+// CheckServiceArea verifies that every stop of the request lies inside the service area of the city,
+// so that requests bound to fail with ERR_OUT_OF_SERVICE_AREA are caught before they are sent.
+// Cities without a known service area are not checked.
+func CheckServiceArea(city CityCode, req *GetQuotationRequest) error {
+	area, ok := ServiceAreas[city]
+	if !ok {
+		return nil
+	}
+	for i, stop := range req.Stops {
+		pt, err := stop.Location.LatLng()
+		if err != nil {
+			return fmt.Errorf("stops[%d]: %w", i, err)
+		}
+		if !area.Contains(pt) {
+			return fmt.Errorf("stops[%d]: %w", i, errOutOfServiceArea)
+		}
+	}
+	return nil
+}
+
+// CityForRequest returns the city whose service area contains every stop of the request.
+func CityForRequest(req *GetQuotationRequest) (CityCode, error) {
+	if len(req.Stops) == 0 {
+		return "", errInsufficientStops
+	}
+	city, ok := CityForLocation(req.Stops[0].Location)
+	if !ok {
+		return "", fmt.Errorf("stops[0]: %w", errOutOfServiceArea)
+	}
+	if err := CheckServiceArea(city, req); err != nil {
+		return "", err
+	}
+	return city, nil
+}
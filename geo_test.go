@@ -0,0 +1,143 @@
+package lalamove
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolygonContains(t *testing.T) {
+	// A concave, L-shaped polygon.
+	l := Polygon{{0, 0}, {0, 2}, {1, 2}, {1, 1}, {2, 1}, {2, 0}}
+	tests := []struct {
+		pt   LatLng
+		want bool
+	}{
+		{LatLng{0.5, 0.5}, true},
+		{LatLng{0.5, 1.5}, true},
+		{LatLng{1.5, 0.5}, true},
+		{LatLng{1.5, 1.5}, false},
+		{LatLng{-0.5, 0.5}, false},
+		{LatLng{0.5, 2.5}, false},
+	}
+	for _, tt := range tests {
+		if got := l.Contains(tt.pt); got != tt.want {
+			t.Errorf("Contains(%v) = %v, want %v", tt.pt, got, tt.want)
+		}
+	}
+}
+
+func TestLocationLatLng(t *testing.T) {
+	tests := []struct {
+		loc     Location
+		want    LatLng
+		wantErr bool
+	}{
+		{Location{Lat: "14.5547", Lng: "121.0244"}, LatLng{14.5547, 121.0244}, false},
+		{Location{Lat: " -6.2554 ", Lng: "106.6011"}, LatLng{-6.2554, 106.6011}, false},
+		{Location{Lat: "", Lng: "121.0244"}, LatLng{}, true},
+		{Location{Lat: "91", Lng: "121.0244"}, LatLng{}, true},
+		{Location{Lat: "14.5547", Lng: "east"}, LatLng{}, true},
+		{Location{Lat: "14.5547", Lng: "-180.5"}, LatLng{}, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.loc.LatLng()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("LatLng(%+v) = %v, %v, want %v, error %v", tt.loc, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestServiceAreasDoNotOverlap(t *testing.T) {
+	for city, area := range ServiceAreas {
+		for other, otherArea := range ServiceAreas {
+			if city == other {
+				continue
+			}
+			for _, vertex := range area {
+				if otherArea.Contains(vertex) {
+					t.Errorf("vertex %v of %s lies in the service area of %s", vertex, city, other)
+				}
+			}
+		}
+	}
+}
+
+func TestCityForLocation(t *testing.T) {
+	tests := []struct {
+		name   string
+		loc    Location
+		want   CityCode
+		wantOK bool
+	}{
+		{"Makati", Location{Lat: "14.5547", Lng: "121.0244"}, CityCodePhilippinesManila, true},
+		{"Cebu IT Park", Location{Lat: "10.3308", Lng: "123.9056"}, CityCodePhilippinesCebu, true},
+		{"Tangerang", Location{Lat: "-6.255431", Lng: "106.601142"}, CityCodeIndonesiaJakarata, true},
+		{"Marina Bay", Location{Lat: "1.2834", Lng: "103.8607"}, CityCodeSingaporeSingapore, true},
+		{"Sao Paulo", Location{Lat: "-23.5505", Lng: "-46.6333"}, CityCodeBrasilSaoPaulo, true},
+		{"Davao", Location{Lat: "7.1907", Lng: "125.4553"}, "", false},
+		{"invalid coordinates", Location{Lat: "north", Lng: "121.0244"}, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := CityForLocation(tt.loc)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("CityForLocation(%s) = %s, %v, want %s, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestCheckServiceArea(t *testing.T) {
+	stops := func(locs ...Location) *GetQuotationRequest {
+		req := &GetQuotationRequest{}
+		for _, loc := range locs {
+			req.Stops = append(req.Stops, Waypoint{Location: loc})
+		}
+		return req
+	}
+	makati := Location{Lat: "14.5547", Lng: "121.0244"}
+	pasig := Location{Lat: "14.5764", Lng: "121.0851"}
+	cebu := Location{Lat: "10.3308", Lng: "123.9056"}
+	tests := []struct {
+		name    string
+		city    CityCode
+		req     *GetQuotationRequest
+		wantErr error
+	}{
+		{"inside", CityCodePhilippinesManila, stops(makati, pasig), nil},
+		{"drop in another city", CityCodePhilippinesManila, stops(makati, cebu), errOutOfServiceArea},
+		{"pickup in another city", CityCodePhilippinesCebu, stops(makati, cebu), errOutOfServiceArea},
+		{"city without a service area", "PH_DVO", stops(makati, cebu), nil},
+	}
+	for _, tt := range tests {
+		if err := CheckServiceArea(tt.city, tt.req); !errors.Is(err, tt.wantErr) {
+			t.Errorf("CheckServiceArea(%s) error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+	if err := CheckServiceArea(CityCodePhilippinesManila, stops(makati, Location{Lat: "x", Lng: "y"})); err == nil {
+		t.Error("CheckServiceArea() accepted invalid coordinates")
+	}
+}
+
+func TestCityForRequest(t *testing.T) {
+	makati := Location{Lat: "14.5547", Lng: "121.0244"}
+	tests := []struct {
+		name    string
+		stops   []Location
+		want    CityCode
+		wantErr error
+	}{
+		{"every stop in Manila", []Location{makati, {Lat: "14.5764", Lng: "121.0851"}}, CityCodePhilippinesManila, nil},
+		{"drop outside Manila", []Location{makati, {Lat: "10.3308", Lng: "123.9056"}}, "", errOutOfServiceArea},
+		{"pickup outside every city", []Location{{Lat: "7.1907", Lng: "125.4553"}, makati}, "", errOutOfServiceArea},
+		{"no stops", nil, "", errInsufficientStops},
+	}
+	for _, tt := range tests {
+		req := &GetQuotationRequest{}
+		for _, loc := range tt.stops {
+			req.Stops = append(req.Stops, Waypoint{Location: loc})
+		}
+		got, err := CityForRequest(req)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("CityForRequest(%s) = %s, %v, want %s, %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package lalamove
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ServiceType is the range of vehicles that Lalamove provides to cater to different needs at different cities.
 type ServiceType string
@@ -168,6 +171,8 @@ type GetQuotationRequest struct {
 type GetQuotationResponse struct {
 	Amount   string `json:"totalFee"`
 	Currency string `json:"totalFeeCurrency"`
+	// PriceBreakdown itemizes the total fee.
+	PriceBreakdown *PriceBreakdown `json:"priceBreakdown,omitempty"`
 }
 
 // Price ...
@@ -193,11 +198,104 @@ type PlaceOrderResponse struct {
 	CustomerOrderID string `json:"customerOrderId"`
 }
 
+// DeliveryStatus is the delivery status of a stop of an order.
+type DeliveryStatus string
+
+// DeliveryStatus enum
+const (
+	// DeliveryStatusPending - The driver has not reached the stop yet.
+	DeliveryStatusPending DeliveryStatus = "PENDING"
+	// DeliveryStatusDelivered - The delivery to the stop was completed.
+	DeliveryStatusDelivered DeliveryStatus = "DELIVERED"
+	// DeliveryStatusFailed - The delivery to the stop could not be completed.
+	DeliveryStatusFailed DeliveryStatus = "FAILED"
+)
+
+// Measurement is a quantity with its unit, e.g. a distance in meters or a load in kilograms.
+type Measurement struct {
+	Value string `json:"value"`
+	Unit  string `json:"unit"`
+}
+
+// ProofOfDelivery ...
+type ProofOfDelivery struct {
+	// SignatureURL is the link to the signature of the recipient.
+	SignatureURL string `json:"signature,omitempty"`
+	// PhotoURL is the link to the photo taken by the driver at delivery.
+	PhotoURL    string     `json:"image,omitempty"`
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+}
+
+// OrderStop ...
+type OrderStop struct {
+	StopID   string           `json:"stopId"`
+	Location Location         `json:"location"`
+	Status   DeliveryStatus   `json:"status"`
+	POD      *ProofOfDelivery `json:"POD,omitempty"`
+}
+
 // OrderDetailsResponse ...
 type OrderDetailsResponse struct {
 	Status   OrderStatus `json:"status"`
 	Price    Price       `json:"price"`
 	DriverID string      `json:"driverId"`
+	// ShareLink is the link to the live tracking page of the order.
+	ShareLink      string          `json:"shareLink,omitempty"`
+	Distance       *Measurement    `json:"distance,omitempty"`
+	PriceBreakdown *PriceBreakdown `json:"priceBreakdown,omitempty"`
+	// Stops are the stops of the order with their delivery status, in the order they are visited.
+	Stops            []OrderStop `json:"stops,omitempty"`
+	CreatedAt        *time.Time  `json:"createdAt,omitempty"`
+	ScheduleAt       *time.Time  `json:"scheduleAt,omitempty"`
+	DriverAssignedAt *time.Time  `json:"driverAssignedAt,omitempty"`
+	CompletedAt      *time.Time  `json:"completedAt,omitempty"`
+	// Raw is the response as received, for fields this package does not model yet.
+	Raw json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the response and keeps a copy of it in Raw.
+func (r *OrderDetailsResponse) UnmarshalJSON(b []byte) error {
+	type plain OrderDetailsResponse
+	if err := json.Unmarshal(b, (*plain)(r)); err != nil {
+		return err
+	}
+	r.Raw = append(json.RawMessage(nil), b...)
+	return nil
+}
+
+// AddPriorityFeeRequest ...
+type AddPriorityFeeRequest struct {
+	PriorityFee Price `json:"priorityFee"`
+}
+
+// ChangeDriverReason is the reason for requesting another driver.
+type ChangeDriverReason string
+
+// ChangeDriverReason enum
+const (
+	// ChangeDriverReasonLate - The driver is late for pick up.
+	ChangeDriverReasonLate ChangeDriverReason = "DRIVER_LATE"
+	// ChangeDriverReasonAskedChange - The driver asked to be changed.
+	ChangeDriverReasonAskedChange ChangeDriverReason = "DRIVER_ASKED_CHANGE"
+	// ChangeDriverReasonUnresponsive - The driver cannot be reached.
+	ChangeDriverReasonUnresponsive ChangeDriverReason = "DRIVER_UNRESPONSIVE"
+	// ChangeDriverReasonRude - The driver was rude.
+	ChangeDriverReasonRude ChangeDriverReason = "DRIVER_RUDE"
+)
+
+// ChangeDriverRequest ...
+type ChangeDriverRequest struct {
+	Reason ChangeDriverReason `json:"reason"`
+}
+
+// EditOrderRequest ...
+type EditOrderRequest struct {
+	// Stops replaces the stops of the order, pick up included (minimum 2, maximum 10).
+	Stops []Waypoint `json:"stops,omitempty"`
+	// Deliveries replaces the delivery information of the order. Required when Stops is set.
+	Deliveries []DeliveryInfo `json:"deliveries,omitempty"`
+	// RequesterContact replaces the contact person at the pick up point.
+	RequesterContact *Contact `json:"requesterContact,omitempty"`
 }
 
 // DriverDetailsResponse ...
@@ -213,6 +311,49 @@ type DriverLocationResponse struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// Dimensions ...
+type Dimensions struct {
+	Length Measurement `json:"length"`
+	Width  Measurement `json:"width"`
+	Height Measurement `json:"height"`
+}
+
+// CitySpecialRequest ...
+type CitySpecialRequest struct {
+	Name        SpecialRequest `json:"name"`
+	Description string         `json:"description"`
+}
+
+// CityServiceType ...
+type CityServiceType struct {
+	Key         ServiceType `json:"key"`
+	Description string      `json:"description"`
+	// Load is the maximum load of the vehicle.
+	Load *Measurement `json:"load,omitempty"`
+	// Dimensions are the maximum dimensions of the cargo.
+	Dimensions      *Dimensions          `json:"dimensions,omitempty"`
+	SpecialRequests []CitySpecialRequest `json:"specialRequests"`
+}
+
+// CityInfo ...
+type CityInfo struct {
+	Locode       CityCode          `json:"locode"`
+	Name         string            `json:"name"`
+	Locales      []Locale          `json:"locales"`
+	ServiceTypes []CityServiceType `json:"services"`
+}
+
+// GetCityInfoResponse ...
+type GetCityInfoResponse struct {
+	Cities []CityInfo `json:"data"`
+}
+
+// Webhook ...
+type Webhook struct {
+	// URL is the endpoint receiving the order updates of the market.
+	URL string `json:"url"`
+}
+
 // ErrorResponse ...
 type ErrorResponse struct {
 	Error string `json:"message"`
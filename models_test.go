@@ -0,0 +1,128 @@
+package lalamove
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOrderDetailsDecoding(t *testing.T) {
+	body := `{
+		"status": "PICKED_UP",
+		"price": {"amount": "183.00", "currency": "PHP"},
+		"driverId": "21712",
+		"shareLink": "https://share.sandbox.lalamove.com/?PH100221017161055012010001&lang=en_PH",
+		"distance": {"value": "8412", "unit": "m"},
+		"priceBreakdown": {
+			"base": "150.00",
+			"extraMileage": "23.00",
+			"specialRequests": {"LALABAG": "10.00"},
+			"priorityFee": "20.00",
+			"surcharges": {"toll": "5.00"},
+			"discount": "-25.00",
+			"total": "183.00",
+			"currency": "PHP"
+		},
+		"stops": [
+			{"stopId": "1", "location": {"lat": "14.5547", "lng": "121.0244"}, "status": "DELIVERED"},
+			{
+				"stopId": "2",
+				"location": {"lat": "14.5764", "lng": "121.0851"},
+				"status": "DELIVERED",
+				"POD": {"signature": "https://pod.lalamove.com/s/2.png", "image": "https://pod.lalamove.com/i/2.jpg", "deliveredAt": "2026-01-01T18:40:00+08:00"}
+			},
+			{"stopId": "3", "location": {"lat": "14.5995", "lng": "120.9842"}, "status": "FAILED", "POD": null}
+		],
+		"createdAt": "2026-01-01T09:00:00Z",
+		"scheduleAt": "2026-01-01T09:30:00Z",
+		"driverAssignedAt": "2026-01-01T09:31:15.5Z",
+		"completedAt": null,
+		"podRequired": true
+	}`
+	got := &OrderDetailsResponse{}
+	if err := json.Unmarshal([]byte(body), got); err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(s string) *time.Time {
+		v, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &v
+	}
+	want := &OrderDetailsResponse{
+		Status:    OrderStatusPickedUp,
+		Price:     Price{Amount: "183.00", Currency: "PHP"},
+		DriverID:  "21712",
+		ShareLink: "https://share.sandbox.lalamove.com/?PH100221017161055012010001&lang=en_PH",
+		Distance:  &Measurement{Value: "8412", Unit: "m"},
+		PriceBreakdown: &PriceBreakdown{
+			Base:            Money{Amount: "150.00", Currency: "PHP"},
+			ExtraMileage:    Money{Amount: "23.00", Currency: "PHP"},
+			SpecialRequests: map[SpecialRequest]Money{SpecialRequestLalabag: {Amount: "10.00", Currency: "PHP"}},
+			PriorityFee:     Money{Amount: "20.00", Currency: "PHP"},
+			Surcharges:      map[string]Money{"toll": {Amount: "5.00", Currency: "PHP"}},
+			Discount:        Money{Amount: "25.00", Currency: "PHP"},
+			Total:           Money{Amount: "183.00", Currency: "PHP"},
+		},
+		Stops: []OrderStop{
+			{StopID: "1", Location: Location{Lat: "14.5547", Lng: "121.0244"}, Status: DeliveryStatusDelivered},
+			{StopID: "2", Location: Location{Lat: "14.5764", Lng: "121.0851"}, Status: DeliveryStatusDelivered, POD: &ProofOfDelivery{
+				SignatureURL: "https://pod.lalamove.com/s/2.png",
+				PhotoURL:     "https://pod.lalamove.com/i/2.jpg",
+				DeliveredAt:  at("2026-01-01T18:40:00+08:00"),
+			}},
+			{StopID: "3", Location: Location{Lat: "14.5995", Lng: "120.9842"}, Status: DeliveryStatusFailed},
+		},
+		CreatedAt:        at("2026-01-01T09:00:00Z"),
+		ScheduleAt:       at("2026-01-01T09:30:00Z"),
+		DriverAssignedAt: at("2026-01-01T09:31:15.5Z"),
+		Raw:              json.RawMessage(body),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded %+v, want %+v", got, want)
+	}
+	if !got.Stops[1].POD.DeliveredAt.Equal(time.Date(2026, 1, 1, 10, 40, 0, 0, time.UTC)) {
+		t.Errorf("DeliveredAt = %s, want 10:40 UTC", got.Stops[1].POD.DeliveredAt)
+	}
+
+	// Fields not modeled yet are kept in Raw.
+	var extra struct {
+		PODRequired bool `json:"podRequired"`
+	}
+	if err := json.Unmarshal(got.Raw, &extra); err != nil || !extra.PODRequired {
+		t.Errorf("Raw does not carry podRequired: %s", got.Raw)
+	}
+}
+
+func TestOrderDetailsDecodingMinimal(t *testing.T) {
+	body := `{"status":"ASSIGNING_DRIVER","price":{"amount":"163.00","currency":"PHP"},"driverId":""}`
+	got := &OrderDetailsResponse{}
+	if err := json.Unmarshal([]byte(body), got); err != nil {
+		t.Fatal(err)
+	}
+	want := &OrderDetailsResponse{
+		Status: OrderStatusAssigningDriver,
+		Price:  Price{Amount: "163.00", Currency: "PHP"},
+		Raw:    json.RawMessage(body),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded %+v, want %+v", got, want)
+	}
+
+	// Decoding into a value already used replaces its Raw.
+	if err := json.Unmarshal([]byte(`{"status":"ON_GOING"}`), got); err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Raw) != `{"status":"ON_GOING"}` {
+		t.Errorf("Raw = %s, want the latest payload", got.Raw)
+	}
+
+	for _, bad := range []string{`{"status":"ON_GOING","createdAt":"yesterday"}`, `{"stops":{}}`, `[]`} {
+		if err := json.Unmarshal([]byte(bad), &OrderDetailsResponse{}); err == nil {
+			t.Errorf("decoding %s succeeded", bad)
+		}
+	}
+}
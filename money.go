@@ -0,0 +1,96 @@
+package lalamove
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// parseAmount parses a decimal amount as returned by the Lalamove APIs, e.g. "108.00".
+// An empty amount is zero.
+func parseAmount(amount string) (*big.Rat, error) {
+	if strings.TrimSpace(amount) == "" {
+		return new(big.Rat), nil
+	}
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	return r, nil
+}
+
+// compareAmounts returns -1, 0 or +1 depending on whether a is less than, equal to or greater than b.
+func compareAmounts(a, b string) (int, error) {
+	x, err := parseAmount(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := parseAmount(b)
+	if err != nil {
+		return 0, err
+	}
+	return x.Cmp(y), nil
+}
+
+// sumPrices adds up prices of the same currency. Prices without a currency are zero values and match
+// any currency. The sum keeps the largest number of decimal places among the amounts.
+func sumPrices(prices []Price) (Price, error) {
+	total := new(big.Rat)
+	sum := Price{}
+	scale := 0
+	for _, p := range prices {
+		if sum.Currency == "" {
+			sum.Currency = p.Currency
+		} else if p.Currency != "" && p.Currency != sum.Currency {
+			return Price{}, fmt.Errorf("cannot add %s to %s: %w", p.Currency, sum.Currency, errInvalidCurrency)
+		}
+		amount, err := parseAmount(p.Amount)
+		if err != nil {
+			return Price{}, err
+		}
+		total.Add(total, amount)
+		if places := decimalPlaces(p.Amount); places > scale {
+			scale = places
+		}
+	}
+	sum.Amount = total.FloatString(scale)
+	return sum, nil
+}
+
+// decimalPlaces returns the number of digits after the decimal point of an amount.
+func decimalPlaces(amount string) int {
+	if i := strings.IndexByte(amount, '.'); i >= 0 {
+		return len(amount) - i - 1
+	}
+	return 0
+}
+
+// Money is an amount in a currency.
+type Money = Price
+
+// Add returns the sum of two amounts of the same currency.
+func (p Price) Add(q Price) (Price, error) {
+	return sumPrices([]Price{p, q})
+}
+
+// Sub returns the difference of two amounts of the same currency.
+func (p Price) Sub(q Price) (Price, error) {
+	if q.Amount == "" {
+		return p, nil
+	}
+	amount, err := parseAmount(q.Amount)
+	if err != nil {
+		return Price{}, err
+	}
+	neg := Price{Amount: amount.Neg(amount).FloatString(decimalPlaces(q.Amount)), Currency: q.Currency}
+	return sumPrices([]Price{p, neg})
+}
+
+// Cmp returns -1, 0 or +1 depending on whether p is less than, equal to or greater than q, which
+// must be of the same currency.
+func (p Price) Cmp(q Price) (int, error) {
+	if p.Currency != q.Currency {
+		return 0, fmt.Errorf("cannot compare %s to %s: %w", p.Currency, q.Currency, errInvalidCurrency)
+	}
+	return compareAmounts(p.Amount, q.Amount)
+}
@@ -0,0 +1,187 @@
+package lalamove
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// maxExactDrops is the largest number of drops whose order is optimized exactly.
+// Larger requests fall back to nearest neighbour with 2-opt improvement.
+const maxExactDrops = 9
+
+// OptimizeStops returns a copy of the request with the drops reordered to minimize the great-circle
+// length of the route. The pickup stays first and every DeliveryInfo.ToStop is rewritten to follow
+// its stop. The request itself is not modified.
+func OptimizeStops(req *GetQuotationRequest) (*GetQuotationRequest, error) {
+	points, err := stopCoordinates(req.Stops)
+	if err != nil {
+		return nil, err
+	}
+	var order []int
+	if len(points)-1 <= maxExactDrops {
+		order = exactStopOrder(points)
+	} else {
+		order = heuristicStopOrder(points)
+	}
+	return reorderStops(req, order)
+}
+
+// OptimizeStopsVerified optimizes the order of the stops, then quotes both the original and the
+// optimized ordering and returns whichever is cheaper along with its quotation. If the original
+// ordering cannot be quoted, the optimized ordering and its quotation are returned.
+func (c *Client) OptimizeStopsVerified(ctx context.Context, city CityCode, req *GetQuotationRequest) (*GetQuotationRequest, *GetQuotationResponse, error) {
+	optimized, err := OptimizeStops(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	optimizedQuote, err := c.GetQuotation(ctx, city, optimized)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sameStopOrder(req, optimized) {
+		return optimized, optimizedQuote, nil
+	}
+	originalQuote, err := c.GetQuotation(ctx, city, req)
+	if err != nil {
+		return optimized, optimizedQuote, nil
+	}
+	cmp, err := compareAmounts(originalQuote.Amount, optimizedQuote.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cmp <= 0 {
+		return req, originalQuote, nil
+	}
+	return optimized, optimizedQuote, nil
+}
+
+// reorderStops builds a copy of req visiting the stops in the given order of original indices.
+func reorderStops(req *GetQuotationRequest, order []int) (*GetQuotationRequest, error) {
+	newIndex := make([]int64, len(order))
+	stops := make([]Waypoint, len(order))
+	for i, old := range order {
+		stops[i] = req.Stops[old]
+		newIndex[old] = int64(i)
+	}
+	deliveries := make([]DeliveryInfo, len(req.Deliveries))
+	for i, d := range req.Deliveries {
+		if d.ToStop < 1 || d.ToStop >= int64(len(order)) {
+			return nil, errDeliveryMismatch
+		}
+		d.ToStop = newIndex[d.ToStop]
+		deliveries[i] = d
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].ToStop < deliveries[j].ToStop
+	})
+	optimized := *req
+	optimized.Stops = stops
+	optimized.Deliveries = deliveries
+	return &optimized, nil
+}
+
+func sameStopOrder(a, b *GetQuotationRequest) bool {
+	for i := range a.Stops {
+		if a.Stops[i].Location != b.Stops[i].Location {
+			return false
+		}
+	}
+	return true
+}
+
+// exactStopOrder solves the open path from points[0] through every other point with Held-Karp.
+func exactStopOrder(points []LatLng) []int {
+	n := len(points) - 1
+	if n <= 1 {
+		return identityOrder(len(points))
+	}
+	full := 1<<uint(n) - 1
+	cost := make([][]float64, full+1)
+	prev := make([][]int, full+1)
+	for mask := range cost {
+		cost[mask] = make([]float64, n)
+		prev[mask] = make([]int, n)
+		for j := range cost[mask] {
+			cost[mask][j] = math.Inf(1)
+		}
+	}
+	for j := 0; j < n; j++ {
+		cost[1<<uint(j)][j] = points[0].DistanceTo(points[j+1])
+		prev[1<<uint(j)][j] = -1
+	}
+	for mask := 1; mask <= full; mask++ {
+		for j := 0; j < n; j++ {
+			if mask&(1<<uint(j)) == 0 || math.IsInf(cost[mask][j], 1) {
+				continue
+			}
+			for k := 0; k < n; k++ {
+				if mask&(1<<uint(k)) != 0 {
+					continue
+				}
+				next := mask | 1<<uint(k)
+				if c := cost[mask][j] + points[j+1].DistanceTo(points[k+1]); c < cost[next][k] {
+					cost[next][k] = c
+					prev[next][k] = j
+				}
+			}
+		}
+	}
+	last := 0
+	for j := 1; j < n; j++ {
+		if cost[full][j] < cost[full][last] {
+			last = j
+		}
+	}
+	order := make([]int, len(points))
+	for mask, j, i := full, last, n; j >= 0; i-- {
+		order[i] = j + 1
+		mask, j = mask&^(1<<uint(j)), prev[mask][j]
+	}
+	return order
+}
+
+// heuristicStopOrder builds an open path from points[0] by nearest neighbour, then improves it with 2-opt.
+func heuristicStopOrder(points []LatLng) []int {
+	order := []int{0}
+	visited := make([]bool, len(points))
+	visited[0] = true
+	for len(order) < len(points) {
+		cur, best := order[len(order)-1], -1
+		for k := range points {
+			if !visited[k] && (best < 0 || points[cur].DistanceTo(points[k]) < points[cur].DistanceTo(points[best])) {
+				best = k
+			}
+		}
+		visited[best] = true
+		order = append(order, best)
+	}
+	for improved := true; improved; {
+		improved = false
+		for i := 1; i < len(order)-1; i++ {
+			for j := i + 1; j < len(order); j++ {
+				before := points[order[i-1]].DistanceTo(points[order[i]])
+				after := points[order[i-1]].DistanceTo(points[order[j]])
+				if j+1 < len(order) {
+					before += points[order[j]].DistanceTo(points[order[j+1]])
+					after += points[order[i]].DistanceTo(points[order[j+1]])
+				}
+				if after < before-1e-6 {
+					for l, r := i, j; l < r; l, r = l+1, r-1 {
+						order[l], order[r] = order[r], order[l]
+					}
+					improved = true
+				}
+			}
+		}
+	}
+	return order
+}
+
+func identityOrder(n int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	return order
+}
@@ -0,0 +1,152 @@
+package lalamove
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// lineRequest builds a request picking up at longitude 121.00 and dropping at each given offset
+// east of it, in that order, with one delivery per drop named after its offset.
+func lineRequest(offsets ...int) *GetQuotationRequest {
+	req := &GetQuotationRequest{ServiceType: ServiceTypeMotorcycle, Stops: []Waypoint{lineStop(0)}}
+	for i, offset := range offsets {
+		req.Stops = append(req.Stops, lineStop(offset))
+		req.Deliveries = append(req.Deliveries, DeliveryInfo{ToStop: int64(i + 1), Contact: Contact{Name: fmt.Sprint(offset), Phone: "+639171234567"}})
+	}
+	return req
+}
+
+func lineStop(offset int) Waypoint {
+	return Waypoint{Location: Location{Lat: "14.5500", Lng: fmt.Sprintf("121.%02d00", offset)}}
+}
+
+// dropOffsets returns the offsets of the drops of a request built by lineRequest, in visiting order,
+// and checks each delivery still points at its stop.
+func dropOffsets(t *testing.T, req *GetQuotationRequest) []int {
+	t.Helper()
+	offsets := make([]int, 0, len(req.Stops)-1)
+	for _, stop := range req.Stops[1:] {
+		var offset int
+		fmt.Sscanf(stop.Location.Lng, "121.%02d00", &offset)
+		offsets = append(offsets, offset)
+	}
+	for _, d := range req.Deliveries {
+		if want := fmt.Sprint(offsets[d.ToStop-1]); d.Contact.Name != want {
+			t.Errorf("delivery of %s points at stop %d, the drop at %s", d.Contact.Name, d.ToStop, want)
+		}
+	}
+	return offsets
+}
+
+func TestOptimizeStops(t *testing.T) {
+	tests := []struct {
+		name    string
+		offsets []int
+		want    []int
+	}{
+		{"single drop", []int{5}, []int{5}},
+		{"already optimal", []int{1, 2, 3}, []int{1, 2, 3}},
+		{"reversed", []int{3, 2, 1}, []int{1, 2, 3}},
+		{"shuffled", []int{4, 1, 9, 2, 7}, []int{1, 2, 4, 7, 9}},
+		{"heuristic", []int{12, 3, 8, 1, 15, 6, 10, 2, 14, 5, 11, 7}, []int{1, 2, 3, 5, 6, 7, 8, 10, 11, 12, 14, 15}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := lineRequest(tt.offsets...)
+			original := lineRequest(tt.offsets...)
+			got, err := OptimizeStops(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if offsets := dropOffsets(t, got); !reflect.DeepEqual(offsets, tt.want) {
+				t.Errorf("drops = %v, want %v", offsets, tt.want)
+			}
+			if got.Stops[0].Location != req.Stops[0].Location {
+				t.Errorf("pickup moved to %v", got.Stops[0])
+			}
+			if !reflect.DeepEqual(req, original) {
+				t.Error("OptimizeStops modified the request")
+			}
+		})
+	}
+}
+
+func TestOptimizeStopsErrors(t *testing.T) {
+	invalid := lineRequest(1, 2)
+	invalid.Stops[2].Location.Lat = "north"
+	if _, err := OptimizeStops(invalid); err == nil {
+		t.Error("OptimizeStops with an invalid location succeeded")
+	}
+
+	mismatch := lineRequest(1, 2)
+	mismatch.Deliveries[1].ToStop = 3
+	if _, err := OptimizeStops(mismatch); !errors.Is(err, errDeliveryMismatch) {
+		t.Errorf("OptimizeStops with a delivery past the last stop = %v, want %v", err, errDeliveryMismatch)
+	}
+}
+
+func TestOptimizeStopsVerified(t *testing.T) {
+	// quoteServer prices each quotation by the longitude of its first drop, failing the
+	// quotations without a price.
+	quoteServer := func(prices map[string]string, quotes *int) *Client {
+		return testClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			*quotes++
+			var body struct {
+				Stops []Waypoint `json:"stops"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			price, ok := prices[body.Stops[1].Location.Lng]
+			if !ok {
+				return stubResponse(req, http.StatusInternalServerError, time.Time{}, ""), nil
+			}
+			return stubResponse(req, http.StatusOK, time.Time{}, fmt.Sprintf(`{"totalFee":"%s","totalFeeCurrency":"PHP"}`, price)), nil
+		}))
+	}
+	tests := []struct {
+		name       string
+		offsets    []int
+		prices     map[string]string
+		wantDrops  []int
+		wantAmount string
+		wantQuotes int
+	}{
+		{"already optimal", []int{1, 2}, map[string]string{"121.0100": "90.00"}, []int{1, 2}, "90.00", 1},
+		{"optimized is cheaper", []int{3, 1, 2}, map[string]string{"121.0100": "90.00", "121.0300": "120.00"}, []int{1, 2, 3}, "90.00", 2},
+		{"original is cheaper", []int{3, 1, 2}, map[string]string{"121.0100": "90.00", "121.0300": "85.00"}, []int{3, 1, 2}, "85.00", 2},
+		{"same price keeps the original", []int{3, 1, 2}, map[string]string{"121.0100": "90.00", "121.0300": "90.00"}, []int{3, 1, 2}, "90.00", 2},
+		{"original fails to quote", []int{3, 1, 2}, map[string]string{"121.0100": "90.00"}, []int{1, 2, 3}, "90.00", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var quotes int
+			c := quoteServer(tt.prices, &quotes)
+			got, quote, err := c.OptimizeStopsVerified(context.Background(), CityCodePhilippinesManila, lineRequest(tt.offsets...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if offsets := dropOffsets(t, got); !reflect.DeepEqual(offsets, tt.wantDrops) {
+				t.Errorf("drops = %v, want %v", offsets, tt.wantDrops)
+			}
+			if quote.Amount != tt.wantAmount {
+				t.Errorf("quote = %s, want %s", quote.Amount, tt.wantAmount)
+			}
+			if quotes != tt.wantQuotes {
+				t.Errorf("quoted %d times, want %d", quotes, tt.wantQuotes)
+			}
+		})
+	}
+
+	var quotes int
+	c := quoteServer(map[string]string{"121.0300": "120.00"}, &quotes)
+	if _, _, err := c.OptimizeStopsVerified(context.Background(), CityCodePhilippinesManila, lineRequest(3, 1, 2)); err == nil {
+		t.Error("OptimizeStopsVerified succeeded when the optimized ordering failed to quote")
+	}
+}
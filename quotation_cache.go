@@ -0,0 +1,147 @@
+package lalamove
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultQuotationTTL is how long Lalamove honours a quotation when placing an order.
+const DefaultQuotationTTL = 5 * time.Minute
+
+// quotationSafetyMargin is how long before the end of its validity a cached quotation stops being
+// reused, leaving time to place the order with it.
+const quotationSafetyMargin = 30 * time.Second
+
+// quotationScheduleBucket is the granularity of the pick up time in a quotation fingerprint.
+const quotationScheduleBucket = 15 * time.Minute
+
+// quotationCoordinatePrecision is the number of decimal places of the coordinates in a quotation
+// fingerprint, about one meter at the equator.
+const quotationCoordinatePrecision = 5
+
+// QuotationCache stores quotations by request fingerprint.
+type QuotationCache interface {
+	// Get returns the quotation stored under key, if it has not expired.
+	Get(ctx context.Context, key string) (*GetQuotationResponse, bool, error)
+	// Set stores the quotation under key for ttl.
+	Set(ctx context.Context, key string, resp *GetQuotationResponse, ttl time.Duration) error
+}
+
+// MemoryQuotationCache is a QuotationCache kept in memory. Once configured on a client with
+// WithQuotationCache, its entries expire by the clock of that client.
+type MemoryQuotationCache struct {
+	cache *ttlCache
+}
+
+// NewMemoryQuotationCache constructs an empty MemoryQuotationCache.
+func NewMemoryQuotationCache() *MemoryQuotationCache {
+	return &MemoryQuotationCache{cache: newTTLCache(time.Now)}
+}
+
+// Get implements QuotationCache.
+func (m *MemoryQuotationCache) Get(ctx context.Context, key string) (*GetQuotationResponse, bool, error) {
+	v, ok := m.cache.get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	return v.(*GetQuotationResponse).clone(), true, nil
+}
+
+// Set implements QuotationCache.
+func (m *MemoryQuotationCache) Set(ctx context.Context, key string, resp *GetQuotationResponse, ttl time.Duration) error {
+	m.cache.set(key, resp.clone(), ttl)
+	return nil
+}
+
+// WithQuotationCache configures GetQuotation to reuse quotations of identical requests. The ttl is
+// how long a quotation is valid, which defaults to DefaultQuotationTTL; quotations are reused until
+// 30 seconds before the end of their validity. Concurrent identical requests share a single API
+// call. Failures of the cache are ignored and the API is called instead.
+func WithQuotationCache(cache QuotationCache, ttl time.Duration) ClientOption {
+	return func(c *Client) error {
+		if ttl <= 0 {
+			ttl = DefaultQuotationTTL
+		}
+		if ttl <= quotationSafetyMargin {
+			return fmt.Errorf("quotation ttl %s is within the safety margin of %s: %w", ttl, quotationSafetyMargin, errInvalidParams)
+		}
+		if m, ok := cache.(*MemoryQuotationCache); ok {
+			m.cache.now = func() time.Time { return c.now() }
+		}
+		c.quotationCache = cache
+		c.quotationTTL = ttl
+		return nil
+	}
+}
+
+// QuotationFingerprint returns a key identifying the quotation requests expected to be priced the
+// same: same city, service type and stops, with coordinates rounded to about a meter, the same set
+// of special requests in any order, and a pick up time in the same 15 minute bucket. Contacts and
+// remarks do not affect the fingerprint.
+func QuotationFingerprint(city CityCode, req *GetQuotationRequest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s\n", city, req.ServiceType)
+	for _, stop := range req.Stops {
+		if pt, err := stop.Location.LatLng(); err == nil {
+			scale := math.Pow10(quotationCoordinatePrecision)
+			fmt.Fprintf(&b, "%.*f,%.*f\n", quotationCoordinatePrecision, math.Round(pt.Lat*scale)/scale,
+				quotationCoordinatePrecision, math.Round(pt.Lng*scale)/scale)
+		} else {
+			fmt.Fprintf(&b, "%s,%s\n", stop.Location.Lat, stop.Location.Lng)
+		}
+	}
+	if req.SpecialRequests != nil {
+		specialRequests := make([]string, 0, len(*req.SpecialRequests))
+		seen := map[SpecialRequest]bool{}
+		for _, sr := range *req.SpecialRequests {
+			if !seen[sr] {
+				seen[sr] = true
+				specialRequests = append(specialRequests, string(sr))
+			}
+		}
+		sort.Strings(specialRequests)
+		b.WriteString(strings.Join(specialRequests, ","))
+	}
+	b.WriteString("\n")
+	if req.ScheduleAt == nil {
+		b.WriteString("now")
+	} else if at, err := time.Parse(time.RFC3339, *req.ScheduleAt); err == nil {
+		b.WriteString(at.UTC().Truncate(quotationScheduleBucket).Format(time.RFC3339))
+	} else {
+		b.WriteString(*req.ScheduleAt)
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Client) cachedQuotation(ctx context.Context, city CityCode, req *GetQuotationRequest) (*GetQuotationResponse, error) {
+	key := QuotationFingerprint(city, req)
+	if resp, ok, err := c.quotationCache.Get(ctx, key); err == nil && ok {
+		return resp, nil
+	}
+	v, err := c.quotationFlight.do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		resp, err := c.getQuotation(ctx, city, req)
+		if err == nil {
+			_ = c.quotationCache.Set(ctx, key, resp, c.quotationTTL-quotationSafetyMargin)
+		}
+		return resp, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*GetQuotationResponse).clone(), nil
+}
+
+func (r *GetQuotationResponse) clone() *GetQuotationResponse {
+	c := *r
+	if r.PriceBreakdown != nil {
+		c.PriceBreakdown = r.PriceBreakdown.clone()
+	}
+	return &c
+}
@@ -0,0 +1,193 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQuotationFingerprint(t *testing.T) {
+	schedule := func(s string) *string { return &s }
+	specialRequests := func(srs ...SpecialRequest) *[]SpecialRequest { return &srs }
+	base := func() *GetQuotationRequest {
+		req := testQuotation()
+		req.SpecialRequests = specialRequests(SpecialRequestCOD, SpecialRequestHelpBuy)
+		req.ScheduleAt = schedule("2026-01-01T10:05:00Z")
+		return req
+	}
+	want := QuotationFingerprint(CityCodePhilippinesManila, base())
+
+	same := []struct {
+		name   string
+		modify func(*GetQuotationRequest)
+	}{
+		{"other contacts", func(r *GetQuotationRequest) {
+			r.RequesterContact = Contact{Name: "Pedro Reyes", Phone: "+639170000000"}
+			r.Deliveries[0].Contact.Phone = "+639171111111"
+		}},
+		{"remarks", func(r *GetQuotationRequest) {
+			remarks := "Leave at the lobby"
+			r.Deliveries[0].Remarks = &remarks
+		}},
+		{"coordinates within a meter", func(r *GetQuotationRequest) { r.Stops[0].Location.Lat = "14.554701" }},
+		{"special requests reordered and repeated", func(r *GetQuotationRequest) {
+			r.SpecialRequests = specialRequests(SpecialRequestHelpBuy, SpecialRequestCOD, SpecialRequestHelpBuy)
+		}},
+		{"same schedule bucket", func(r *GetQuotationRequest) { r.ScheduleAt = schedule("2026-01-01T18:14:59+08:00") }},
+	}
+	for _, tt := range same {
+		req := base()
+		tt.modify(req)
+		if got := QuotationFingerprint(CityCodePhilippinesManila, req); got != want {
+			t.Errorf("QuotationFingerprint(%s) differs from the original request", tt.name)
+		}
+	}
+
+	different := []struct {
+		name   string
+		city   CityCode
+		modify func(*GetQuotationRequest)
+	}{
+		{"other city", CityCodePhilippinesCebu, func(r *GetQuotationRequest) {}},
+		{"other service", CityCodePhilippinesManila, func(r *GetQuotationRequest) { r.ServiceType = ServiceTypeVan }},
+		{"moved stop", CityCodePhilippinesManila, func(r *GetQuotationRequest) { r.Stops[1].Location.Lng = "121.0852" }},
+		{"reversed stops", CityCodePhilippinesManila, func(r *GetQuotationRequest) { r.Stops[0], r.Stops[1] = r.Stops[1], r.Stops[0] }},
+		{"fewer special requests", CityCodePhilippinesManila, func(r *GetQuotationRequest) { r.SpecialRequests = specialRequests(SpecialRequestCOD) }},
+		{"next schedule bucket", CityCodePhilippinesManila, func(r *GetQuotationRequest) { r.ScheduleAt = schedule("2026-01-01T10:15:00Z") }},
+		{"immediate", CityCodePhilippinesManila, func(r *GetQuotationRequest) { r.ScheduleAt = nil }},
+	}
+	for _, tt := range different {
+		req := base()
+		tt.modify(req)
+		if got := QuotationFingerprint(tt.city, req); got == want {
+			t.Errorf("QuotationFingerprint(%s) is the same as the original request", tt.name)
+		}
+	}
+}
+
+// quotationServer quotes every request, counting the quotations.
+type quotationServer struct {
+	mu     sync.Mutex
+	quotes int
+	delay  time.Duration
+}
+
+func (s *quotationServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	s.quotes++
+	s.mu.Unlock()
+	time.Sleep(s.delay)
+	return stubResponse(req, http.StatusOK, time.Time{}, `{
+		"totalFee": "163.00",
+		"totalFeeCurrency": "PHP",
+		"priceBreakdown": {"base": "150.00", "surcharges": {"toll": "13.00"}, "total": "163.00", "currency": "PHP"}
+	}`), nil
+}
+
+func (s *quotationServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.quotes
+}
+
+func TestQuotationCache(t *testing.T) {
+	now := testNow
+	srv := &quotationServer{}
+	c := testClient(t, srv, WithClock(func() time.Time { return now }), WithQuotationCache(NewMemoryQuotationCache(), 0))
+	ctx := context.Background()
+
+	first, err := c.GetQuotation(ctx, CityCodePhilippinesManila, testQuotation())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Amount = "0"
+	first.PriceBreakdown.Surcharges["toll"] = Money{Amount: "0"}
+
+	// A quotation is reused until the safety margin before the end of its validity, measured by
+	// the clock of the client.
+	now = now.Add(DefaultQuotationTTL - quotationSafetyMargin - time.Second)
+	second, err := c.GetQuotation(ctx, CityCodePhilippinesManila, testQuotation())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srv.count() != 1 {
+		t.Errorf("sent %d quotations within the TTL, want 1", srv.count())
+	}
+	if second.Amount != "163.00" || second.PriceBreakdown.Surcharges["toll"].Amount != "13.00" {
+		t.Errorf("cached quotation = %+v, want the quotation as received", second)
+	}
+
+	now = now.Add(time.Second)
+	if _, err := c.GetQuotation(ctx, CityCodePhilippinesManila, testQuotation()); err != nil {
+		t.Fatal(err)
+	}
+	if srv.count() != 2 {
+		t.Errorf("sent %d quotations once the safety margin was reached, want 2", srv.count())
+	}
+}
+
+func TestQuotationCacheTTL(t *testing.T) {
+	now := testNow
+	srv := &quotationServer{}
+	c := testClient(t, srv, WithClock(func() time.Time { return now }), WithQuotationCache(NewMemoryQuotationCache(), time.Minute))
+	c.GetQuotation(context.Background(), CityCodePhilippinesManila, testQuotation())
+	now = now.Add(time.Minute - quotationSafetyMargin)
+	c.GetQuotation(context.Background(), CityCodePhilippinesManila, testQuotation())
+	if srv.count() != 2 {
+		t.Errorf("sent %d quotations, want 2", srv.count())
+	}
+
+	_, err := NewClient(WithEnvironment(Sandbox), WithAPIKey("pk_test_0123456789"), WithSecret("sk_test_0123456789"),
+		WithQuotationCache(NewMemoryQuotationCache(), quotationSafetyMargin))
+	if !errors.Is(err, errInvalidParams) {
+		t.Errorf("NewClient() with a TTL within the safety margin = %v, want %v", err, errInvalidParams)
+	}
+}
+
+func TestQuotationCacheSharesConcurrentRequests(t *testing.T) {
+	srv := &quotationServer{delay: 20 * time.Millisecond}
+	c := testClient(t, srv, WithQuotationCache(NewMemoryQuotationCache(), 0))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := c.GetQuotation(context.Background(), CityCodePhilippinesManila, testQuotation())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.PriceBreakdown.Surcharges["toll"] = Money{Amount: "0"}
+		}()
+	}
+	wg.Wait()
+	if srv.count() != 1 {
+		t.Errorf("sent %d quotations for concurrent identical requests, want 1", srv.count())
+	}
+}
+
+type failingQuotationCache struct{}
+
+func (failingQuotationCache) Get(ctx context.Context, key string) (*GetQuotationResponse, bool, error) {
+	return nil, false, errStoreUnavailable
+}
+
+func (failingQuotationCache) Set(ctx context.Context, key string, resp *GetQuotationResponse, ttl time.Duration) error {
+	return errStoreUnavailable
+}
+
+func TestQuotationCacheFailures(t *testing.T) {
+	srv := &quotationServer{}
+	c := testClient(t, srv, WithQuotationCache(failingQuotationCache{}, 0))
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetQuotation(context.Background(), CityCodePhilippinesManila, testQuotation()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if srv.count() != 2 {
+		t.Errorf("sent %d quotations with a failing cache, want 2", srv.count())
+	}
+}
@@ -0,0 +1,210 @@
+package lalamove
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+)
+
+// defaultRouteInterval is the time a scripted driver takes between two points of its route.
+const defaultRouteInterval = 10 * time.Second
+
+// ScenarioDuration is a duration written in scenario files as a string such as "2s" or "1m30s".
+type ScenarioDuration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d ScenarioDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *ScenarioDuration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("invalid duration %s: %w", b, err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = ScenarioDuration(v)
+	return nil
+}
+
+// Scenario scripts the responses of a FakeServer. Times of order endpoints are relative to the
+// placement of the order; times of other endpoints are relative to the first request served.
+type Scenario struct {
+	Name string `json:"name,omitempty"`
+	// APIKey and Secret, if set, are checked against the signature of every request.
+	APIKey string `json:"apiKey,omitempty"`
+	Secret string `json:"secret,omitempty"`
+	// Quotation is returned for every quotation request. Orders must be placed with its total fee.
+	Quotation GetQuotationResponse `json:"quotation"`
+	Order     ScenarioOrder        `json:"order"`
+	Driver    ScenarioDriver       `json:"driver"`
+	// Cities are returned by the city info endpoint.
+	Cities []CityInfo `json:"cities,omitempty"`
+	// Failures are windows of time during which an endpoint fails.
+	Failures []ScenarioFailure `json:"failures,omitempty"`
+}
+
+// ScenarioOrder scripts the life of every order placed.
+type ScenarioOrder struct {
+	// OrderID is the ID of the first order placed. Later orders get a numeric suffix.
+	OrderID   string `json:"orderId,omitempty"`
+	ShareLink string `json:"shareLink,omitempty"`
+	// Timeline are the status changes of the order after its placement. Orders start ASSIGNING_DRIVER.
+	Timeline []ScenarioStep `json:"timeline"`
+}
+
+// ScenarioStep is a status change of a scripted order.
+type ScenarioStep struct {
+	After  ScenarioDuration `json:"after"`
+	Status OrderStatus      `json:"status"`
+}
+
+// ScenarioDriver is the driver assigned to scripted orders once they are ON_GOING.
+type ScenarioDriver struct {
+	ID string `json:"id,omitempty"`
+	DriverDetailsResponse
+	// Polyline is the route walked by the driver once assigned, as an encoded polyline.
+	Polyline string `json:"polyline,omitempty"`
+	// Path is the route walked by the driver once assigned, if Polyline is not set. Without a
+	// route, the driver waits at the pick up point.
+	Path []Location `json:"path,omitempty"`
+	// Interval is the time taken between two points of the route. Defaults to 10 seconds.
+	Interval ScenarioDuration `json:"interval,omitempty"`
+}
+
+// ScenarioFailure makes an endpoint fail for a window of time.
+type ScenarioFailure struct {
+	// Endpoint is the name of the Client method calling the endpoint, e.g. "DriverLocation".
+	Endpoint string           `json:"endpoint"`
+	From     ScenarioDuration `json:"from,omitempty"`
+	// Until ends the window. Zero never ends it.
+	Until ScenarioDuration `json:"until,omitempty"`
+	// StatusCode is the status of the failed responses. Defaults to 403.
+	StatusCode int `json:"statusCode,omitempty"`
+	// Error is the error code of the failed responses, e.g. ERR_INSUFFICIENT_CREDIT.
+	Error string `json:"error,omitempty"`
+}
+
+// active reports whether the window is open at the given time.
+func (f *ScenarioFailure) active(elapsed time.Duration) bool {
+	return elapsed >= time.Duration(f.From) && (f.Until == 0 || elapsed < time.Duration(f.Until))
+}
+
+// LoadScenario reads and validates a JSON scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scenario{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Validate checks the scenario is consistent and sorts the timeline.
+func (s *Scenario) Validate() error {
+	if _, err := parseAmount(s.Quotation.Amount); err != nil {
+		return fmt.Errorf("quotation: %w", err)
+	}
+	sort.SliceStable(s.Order.Timeline, func(i, j int) bool {
+		return s.Order.Timeline[i].After < s.Order.Timeline[j].After
+	})
+	for _, step := range s.Order.Timeline {
+		if !step.Status.IsKnown() {
+			return fmt.Errorf("timeline: unknown status %q", step.Status)
+		}
+	}
+	if _, err := s.Driver.route(); err != nil {
+		return fmt.Errorf("driver: %w", err)
+	}
+	for _, f := range s.Failures {
+		if !fakeEndpoints[f.Endpoint] {
+			return fmt.Errorf("failures: unknown endpoint %q", f.Endpoint)
+		}
+		if f.Until != 0 && f.Until <= f.From {
+			return fmt.Errorf("failures: empty window for %s", f.Endpoint)
+		}
+	}
+	return nil
+}
+
+// route returns the points walked by the driver.
+func (d *ScenarioDriver) route() ([]LatLng, error) {
+	if d.Polyline != "" {
+		return decodePolyline(d.Polyline)
+	}
+	points := make([]LatLng, len(d.Path))
+	for i, l := range d.Path {
+		pt, err := l.LatLng()
+		if err != nil {
+			return nil, err
+		}
+		points[i] = pt
+	}
+	return points, nil
+}
+
+// positionAt returns the point of the route reached after walking it for the given time, moving
+// in a straight line between points.
+func positionAt(route []LatLng, interval, elapsed time.Duration) LatLng {
+	if elapsed <= 0 || len(route) == 1 {
+		return route[0]
+	}
+	i := int(elapsed / interval)
+	if i >= len(route)-1 {
+		return route[len(route)-1]
+	}
+	frac := float64(elapsed-time.Duration(i)*interval) / float64(interval)
+	return LatLng{
+		Lat: route[i].Lat + (route[i+1].Lat-route[i].Lat)*frac,
+		Lng: route[i].Lng + (route[i+1].Lng-route[i].Lng)*frac,
+	}
+}
+
+// decodePolyline decodes a polyline in the encoded polyline algorithm format, with 5 decimal places.
+func decodePolyline(s string) ([]LatLng, error) {
+	var points []LatLng
+	var lat, lng int64
+	for i := 0; i < len(s); {
+		var deltas [2]int64
+		for k := range deltas {
+			var result int64
+			var shift uint
+			for {
+				if i >= len(s) {
+					return nil, fmt.Errorf("truncated polyline")
+				}
+				b := int64(s[i]) - 63
+				i++
+				if b < 0 || b > 63 {
+					return nil, fmt.Errorf("invalid polyline character %q", s[i-1])
+				}
+				result |= (b & 0x1f) << shift
+				shift += 5
+				if b < 0x20 {
+					break
+				}
+			}
+			if result&1 != 0 {
+				deltas[k] = ^(result >> 1)
+			} else {
+				deltas[k] = result >> 1
+			}
+		}
+		lat += deltas[0]
+		lng += deltas[1]
+		points = append(points, LatLng{Lat: float64(lat) / 1e5, Lng: float64(lng) / 1e5})
+	}
+	return points, nil
+}
@@ -0,0 +1,129 @@
+package lalamove
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDecodePolyline(t *testing.T) {
+	tests := []struct {
+		name     string
+		polyline string
+		want     []LatLng
+		wantErr  bool
+	}{
+		{
+			name:     "reference example",
+			polyline: "_p~iF~ps|U_ulLnnqC_mqNvxq`@",
+			want:     []LatLng{{38.5, -120.2}, {40.7, -120.95}, {43.252, -126.453}},
+		},
+		{
+			name:     "single point",
+			polyline: "_p~iF~ps|U",
+			want:     []LatLng{{38.5, -120.2}},
+		},
+		{
+			name: "empty",
+		},
+		{
+			name:     "truncated",
+			polyline: "_p~iF~ps|",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid character",
+			polyline: "_p~iF ps|U",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodePolyline(tt.polyline)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodePolyline(%q) error = %v, want error %v", tt.polyline, err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("decodePolyline(%q) = %v, want %v", tt.polyline, got, tt.want)
+			}
+			for i := range got {
+				if math.Abs(got[i].Lat-tt.want[i].Lat) > 1e-9 || math.Abs(got[i].Lng-tt.want[i].Lng) > 1e-9 {
+					t.Errorf("decodePolyline(%q)[%d] = %v, want %v", tt.polyline, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPositionAt(t *testing.T) {
+	route := []LatLng{{14.5, 121.0}, {14.6, 121.0}, {14.6, 121.2}}
+	tests := []struct {
+		elapsed time.Duration
+		want    LatLng
+	}{
+		{-time.Second, route[0]},
+		{0, route[0]},
+		{5 * time.Second, LatLng{14.55, 121.0}},
+		{10 * time.Second, route[1]},
+		{15 * time.Second, LatLng{14.6, 121.1}},
+		{time.Minute, route[2]},
+	}
+	for _, tt := range tests {
+		got := positionAt(route, 10*time.Second, tt.elapsed)
+		if math.Abs(got.Lat-tt.want.Lat) > 1e-9 || math.Abs(got.Lng-tt.want.Lng) > 1e-9 {
+			t.Errorf("positionAt(%s) = %v, want %v", tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+func TestLoadScenario(t *testing.T) {
+	s, err := LoadScenario("testdata/scenarios/recording.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Order.OrderID == "" || len(s.Order.Timeline) == 0 || len(s.Cities) == 0 {
+		t.Errorf("scenario = %+v, want an order timeline and cities", s)
+	}
+	if _, err := LoadScenario("testdata/scenarios/missing.json"); err == nil {
+		t.Error("LoadScenario() of a missing file = nil, want an error")
+	}
+}
+
+func TestScenarioValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(s *Scenario)
+		valid  bool
+	}{
+		{"valid", func(s *Scenario) {}, true},
+		{"polyline", func(s *Scenario) { s.Driver.Polyline = "_p~iF~ps|U_ulLnnqC" }, true},
+		{"invalid quotation amount", func(s *Scenario) { s.Quotation.Amount = "free" }, false},
+		{"unknown status", func(s *Scenario) {
+			s.Order.Timeline = append(s.Order.Timeline, ScenarioStep{After: ScenarioDuration(time.Hour), Status: "ARRIVED"})
+		}, false},
+		{"invalid polyline", func(s *Scenario) { s.Driver.Polyline = "_p~iF~ps|" }, false},
+		{"invalid path", func(s *Scenario) { s.Driver.Path = []Location{{Lat: "north", Lng: "121.0"}} }, false},
+		{"unknown failure endpoint", func(s *Scenario) { s.Failures = []ScenarioFailure{{Endpoint: "Teleport"}} }, false},
+		{"empty failure window", func(s *Scenario) {
+			s.Failures = []ScenarioFailure{{Endpoint: "PlaceOrder", From: ScenarioDuration(time.Minute), Until: ScenarioDuration(time.Minute)}}
+		}, false},
+	}
+	for _, tt := range tests {
+		s := testScenario()
+		tt.modify(s)
+		if err := s.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate(%s) = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+
+	s := testScenario()
+	s.Order.Timeline[0], s.Order.Timeline[2] = s.Order.Timeline[2], s.Order.Timeline[0]
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(s.Order.Timeline); i++ {
+		if s.Order.Timeline[i].After < s.Order.Timeline[i-1].After {
+			t.Errorf("timeline not sorted: %+v", s.Order.Timeline)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package lalamove
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// jsonSchemaDraft is the JSON Schema version of the generated schemas.
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// schemaType is the type keyword of a JSON Schema, a single type or a list of types.
+type schemaType []string
+
+// MarshalJSON implements json.Marshaler.
+func (t schemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *schemaType) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*t = schemaType{one}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+// jsonSchema is the subset of JSON Schema needed to describe the wire format of the models.
+// Objects accept properties they do not describe, as the APIs may add fields at any time.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 schemaType             `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaWireTypes are types with a custom JSON encoding, described by the type they are encoded as.
+var schemaWireTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(PriceBreakdown{}): reflect.TypeOf(priceBreakdownJSON{}),
+}
+
+// schemaFor generates the JSON Schema of the JSON encoding of v, following its struct tags.
+func schemaFor(v interface{}) *jsonSchema {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	s := schemaForType(t)
+	s.Schema = jsonSchemaDraft
+	s.Title = t.Name()
+	return s
+}
+
+func schemaForType(t reflect.Type) *jsonSchema {
+	if wire, ok := schemaWireTypes[t]; ok {
+		t = wire
+	}
+	switch {
+	case t == timeType:
+		return &jsonSchema{Type: schemaType{"string"}, Format: "date-time"}
+	case t == rawMessageType:
+		return &jsonSchema{}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(schemaForType(t.Elem()))
+	case reflect.String:
+		return &jsonSchema{Type: schemaType{"string"}}
+	case reflect.Bool:
+		return &jsonSchema{Type: schemaType{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: schemaType{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: schemaType{"number"}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &jsonSchema{Type: schemaType{"string"}}
+		}
+		return nullable(&jsonSchema{Type: schemaType{"array"}, Items: schemaForType(t.Elem())})
+	case reflect.Map:
+		return nullable(&jsonSchema{Type: schemaType{"object"}, AdditionalProperties: schemaForType(t.Elem())})
+	case reflect.Struct:
+		s := &jsonSchema{Type: schemaType{"object"}, Properties: map[string]*jsonSchema{}}
+		addStructFields(s, t)
+		return s
+	}
+	return &jsonSchema{}
+}
+
+// addStructFields adds the fields of the struct to the schema, flattening embedded structs as
+// encoding/json does.
+func addStructFields(s *jsonSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+		name, opts := tag, ""
+		if i := strings.IndexByte(tag, ','); i >= 0 {
+			name, opts = tag[:i], tag[i+1:]
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			addStructFields(s, f.Type)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = schemaForType(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func nullable(s *jsonSchema) *jsonSchema {
+	if len(s.Type) > 0 && !s.allows("null") {
+		s.Type = append(s.Type, "null")
+	}
+	return s
+}
+
+// schemaError lists the violations of a JSON document against a schema.
+type schemaError struct {
+	Violations []string
+}
+
+func (e *schemaError) Error() string {
+	return strings.Join(e.Violations, "; ")
+}
+
+// check validates the JSON document against the schema.
+func (s *jsonSchema) check(doc []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	e := &schemaError{}
+	s.validate("$", v, e)
+	if len(e.Violations) > 0 {
+		return e
+	}
+	return nil
+}
+
+func (s *jsonSchema) validate(path string, v interface{}, e *schemaError) {
+	if len(s.Type) > 0 && !s.allows(jsonType(v)) {
+		e.Violations = append(e.Violations, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(s.Type, " or "), jsonType(v)))
+		return
+	}
+	switch v := v.(type) {
+	case string:
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				e.Violations = append(e.Violations, fmt.Sprintf("%s: invalid date-time %q", path, v))
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, e)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				e.Violations = append(e.Violations, fmt.Sprintf("%s: missing %s", path, name))
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if prop, ok := s.Properties[k]; ok {
+				prop.validate(path+"."+k, v[k], e)
+			} else if s.AdditionalProperties != nil {
+				s.AdditionalProperties.validate(path+"."+k, v[k], e)
+			}
+		}
+	}
+}
+
+// allows reports whether the schema accepts values of the JSON type. Integers are numbers too.
+func (s *jsonSchema) allows(typ string) bool {
+	for _, t := range s.Type {
+		if t == typ || (t == "number" && typ == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON Schema type of a value decoded with UseNumber.
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if strings.ContainsAny(string(v), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,250 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// maxDropsPerOrder is the number of drops a single order can carry besides its pickup.
+const maxDropsPerOrder = 9
+
+var errQuotesPlanMismatch = errors.New("quotations do not match the batches of the plan")
+
+// OrderBatch is one of the orders an oversize job is split into.
+type OrderBatch struct {
+	// Request is the quotation request of the batch, starting at the shared pickup.
+	Request *GetQuotationRequest
+	// Drops are the indices in the original request of the stops carried by the batch.
+	Drops []int
+}
+
+// SplitPlan is the set of orders covering every drop of a job.
+type SplitPlan struct {
+	Batches []OrderBatch
+}
+
+// SplitRequest splits a job with any number of drops into orders of at most 9 drops sharing the
+// pickup at stops[0]. Drops are clustered by bearing from the pickup so each order serves one
+// direction, and the drops of each order are visited in optimized order. Jobs that fit in a single
+// order are returned as a single batch.
+func SplitRequest(req *GetQuotationRequest) (*SplitPlan, error) {
+	if len(req.Stops) < 2 {
+		return nil, errInsufficientStops
+	}
+	points, err := stopCoordinates(req.Stops)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := map[int64][]DeliveryInfo{}
+	for _, d := range req.Deliveries {
+		if d.ToStop < 1 || d.ToStop >= int64(len(req.Stops)) {
+			return nil, errDeliveryMismatch
+		}
+		deliveries[d.ToStop] = append(deliveries[d.ToStop], d)
+	}
+
+	plan := &SplitPlan{}
+	for _, drops := range sweepClusters(points) {
+		batch := *req
+		batch.Stops = []Waypoint{req.Stops[0]}
+		batch.Deliveries = nil
+		for _, drop := range drops {
+			for _, d := range deliveries[int64(drop)] {
+				d.ToStop = int64(len(batch.Stops))
+				batch.Deliveries = append(batch.Deliveries, d)
+			}
+			batch.Stops = append(batch.Stops, req.Stops[drop])
+		}
+		optimized, err := OptimizeStops(&batch)
+		if err != nil {
+			return nil, err
+		}
+		plan.Batches = append(plan.Batches, OrderBatch{Request: optimized, Drops: drops})
+	}
+	return plan, nil
+}
+
+// sweepClusters sorts the drops by bearing from the pickup, starting after the widest angular gap,
+// and cuts them into the fewest batches of balanced size.
+func sweepClusters(points []LatLng) [][]int {
+	type drop struct {
+		index   int
+		bearing float64
+	}
+	drops := make([]drop, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		dy := points[i].Lat - points[0].Lat
+		dx := (points[i].Lng - points[0].Lng) * math.Cos(points[0].Lat*math.Pi/180)
+		drops = append(drops, drop{index: i, bearing: math.Atan2(dy, dx)})
+	}
+	sort.SliceStable(drops, func(i, j int) bool {
+		return drops[i].bearing < drops[j].bearing
+	})
+	start, widest := 0, 0.0
+	for i := range drops {
+		prev := drops[(i+len(drops)-1)%len(drops)].bearing
+		gap := drops[i].bearing - prev
+		if gap <= 0 {
+			gap += 2 * math.Pi
+		}
+		if gap > widest {
+			start, widest = i, gap
+		}
+	}
+	drops = append(append([]drop(nil), drops[start:]...), drops[:start]...)
+
+	n := len(drops)
+	k := (n + maxDropsPerOrder - 1) / maxDropsPerOrder
+	clusters := make([][]int, 0, k)
+	for b, from := 0, 0; b < k; b++ {
+		to := from + (n-from)/(k-b)
+		if (n-from)%(k-b) != 0 {
+			to++
+		}
+		cluster := make([]int, 0, to-from)
+		for _, d := range drops[from:to] {
+			cluster = append(cluster, d.index)
+		}
+		clusters = append(clusters, cluster)
+		from = to
+	}
+	return clusters
+}
+
+// BatchQuotation is the quotation of one batch of a SplitPlan.
+type BatchQuotation struct {
+	Quotation *GetQuotationResponse
+	Err       error
+}
+
+// GroupQuotation is the quotation of every batch of a SplitPlan.
+type GroupQuotation struct {
+	// Quotations are aligned with the batches of the plan.
+	Quotations []BatchQuotation
+	// Total is the sum of the successful quotations.
+	Total Price
+}
+
+// Err returns an error describing the batches which could not be quoted, if any.
+func (q *GroupQuotation) Err() error {
+	errs := make([]error, len(q.Quotations))
+	for i, bq := range q.Quotations {
+		errs[i] = bq.Err
+	}
+	return batchErrors("quote", errs)
+}
+
+// QuoteBatches quotes every batch of the plan. Batches that fail to quote are reported in the result
+// and excluded from the total; the returned error is only set when the total cannot be computed, in
+// which case the per-batch quotations are still returned along with it.
+func (c *Client) QuoteBatches(ctx context.Context, city CityCode, plan *SplitPlan) (*GroupQuotation, error) {
+	group := &GroupQuotation{Quotations: make([]BatchQuotation, len(plan.Batches))}
+	var prices []Price
+	for i, batch := range plan.Batches {
+		resp, err := c.GetQuotation(ctx, city, batch.Request)
+		group.Quotations[i] = BatchQuotation{Quotation: resp, Err: err}
+		if err == nil {
+			prices = append(prices, Price{Amount: resp.Amount, Currency: resp.Currency})
+		}
+	}
+	total, err := sumPrices(prices)
+	if err != nil {
+		return group, err
+	}
+	group.Total = total
+	return group, nil
+}
+
+// BatchOrder is the order placed for one batch of a SplitPlan.
+type BatchOrder struct {
+	Order *PlaceOrderResponse
+	Err   error
+	// Canceled is set if the order was canceled because another batch failed.
+	Canceled bool
+}
+
+// GroupOrder is the set of orders placed for a SplitPlan.
+type GroupOrder struct {
+	// Orders are aligned with the batches of the plan.
+	Orders []BatchOrder
+	// Total is the quoted total of the orders that were placed and not canceled.
+	Total Price
+}
+
+// Err returns an error describing the batches which could not be placed, if any.
+func (g *GroupOrder) Err() error {
+	errs := make([]error, len(g.Orders))
+	for i, bo := range g.Orders {
+		errs[i] = bo.Err
+	}
+	return batchErrors("place", errs)
+}
+
+// PlaceBatches places an order for every batch of the plan at its quoted price. Batches without a
+// successful quotation are not placed. If atomic is set and any batch fails, the orders already
+// placed are canceled on a best-effort basis. Per-batch outcomes are reported in the result; use
+// GroupOrder.Err to check for partial failure. If the total of the placed orders cannot be computed,
+// the result is returned along with the error so the placed orders are not lost.
+func (c *Client) PlaceBatches(ctx context.Context, city CityCode, plan *SplitPlan, quotes *GroupQuotation, sendSms *bool, atomic bool) (*GroupOrder, error) {
+	if len(quotes.Quotations) != len(plan.Batches) {
+		return nil, errQuotesPlanMismatch
+	}
+	group := &GroupOrder{Orders: make([]BatchOrder, len(plan.Batches))}
+	failed := false
+	for i, batch := range plan.Batches {
+		quote := quotes.Quotations[i]
+		if quote.Err != nil {
+			group.Orders[i].Err = quote.Err
+			failed = true
+			continue
+		}
+		if failed && atomic {
+			group.Orders[i].Err = fmt.Errorf("not placed after an earlier batch failed")
+			continue
+		}
+		req := &PlaceOrderRequest{
+			QuotedPrice:         Price{Amount: quote.Quotation.Amount, Currency: quote.Quotation.Currency},
+			SendSms:             sendSms,
+			GetQuotationRequest: *batch.Request,
+		}
+		resp, err := c.PlaceOrder(ctx, city, req)
+		group.Orders[i] = BatchOrder{Order: resp, Err: err}
+		failed = failed || err != nil
+	}
+	if failed && atomic {
+		for i := range group.Orders {
+			if order := group.Orders[i].Order; order != nil {
+				group.Orders[i].Canceled = c.CancelOrder(ctx, city, order.OrderID) == nil
+			}
+		}
+	}
+	var prices []Price
+	for i, bo := range group.Orders {
+		if bo.Order != nil && !bo.Canceled {
+			prices = append(prices, Price{Amount: quotes.Quotations[i].Quotation.Amount, Currency: quotes.Quotations[i].Quotation.Currency})
+		}
+	}
+	total, err := sumPrices(prices)
+	if err != nil {
+		return group, err
+	}
+	group.Total = total
+	return group, nil
+}
+
+func batchErrors(op string, errs []error) error {
+	var msgs []string
+	for i, err := range errs {
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("batch %d: %s", i, err))
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("failed to %s %d of %d batches: %s", op, len(msgs), len(errs), strings.Join(msgs, "; "))
+}
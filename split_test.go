@@ -0,0 +1,157 @@
+package lalamove
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+)
+
+// ring places drops around the pickup at the given bearings in degrees, 1km away.
+func ring(bearings ...float64) []LatLng {
+	pickup := LatLng{Lat: 14.55, Lng: 121.02}
+	points := []LatLng{pickup}
+	for _, b := range bearings {
+		rad := b * math.Pi / 180
+		points = append(points, LatLng{
+			Lat: pickup.Lat + 0.009*math.Sin(rad),
+			Lng: pickup.Lng + 0.009*math.Cos(rad)/math.Cos(pickup.Lat*math.Pi/180),
+		})
+	}
+	return points
+}
+
+func spread(from, step float64, n int) []float64 {
+	bearings := make([]float64, n)
+	for i := range bearings {
+		bearings[i] = from + float64(i)*step
+	}
+	return bearings
+}
+
+func TestSweepClusters(t *testing.T) {
+	tests := []struct {
+		name     string
+		bearings []float64
+		sizes    []int
+		// sides, if set, is the side of each drop; drops of a side must share their cluster.
+		sides []int
+	}{
+		{
+			name:     "fits one order",
+			bearings: spread(0, 40, 9),
+			sizes:    []int{9},
+		},
+		{
+			name:     "balanced halves",
+			bearings: spread(0, 36, 10),
+			sizes:    []int{5, 5},
+		},
+		{
+			name:     "balanced thirds",
+			bearings: spread(0, 360.0/19, 19),
+			sizes:    []int{7, 6, 6},
+		},
+		{
+			name:     "opposite sides",
+			bearings: append(spread(-8, 2, 9), spread(172, 2, 9)...),
+			sizes:    []int{9, 9},
+			sides:    []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+		},
+		{
+			name:     "cluster across the wrap around",
+			bearings: append(spread(170, 2, 9), spread(-10, 2, 9)...),
+			sizes:    []int{9, 9},
+			sides:    []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters := sweepClusters(ring(tt.bearings...))
+			if len(clusters) != len(tt.sizes) {
+				t.Fatalf("got %d clusters, want %d: %v", len(clusters), len(tt.sizes), clusters)
+			}
+			var all []int
+			for i, cluster := range clusters {
+				if len(cluster) != tt.sizes[i] {
+					t.Errorf("cluster %d has %d drops, want %d", i, len(cluster), tt.sizes[i])
+				}
+				for _, drop := range cluster {
+					if tt.sides != nil && tt.sides[drop-1] != tt.sides[cluster[0]-1] {
+						t.Errorf("cluster %d mixes sides: %v", i, cluster)
+						break
+					}
+				}
+				all = append(all, cluster...)
+			}
+			sort.Ints(all)
+			for i, drop := range all {
+				if drop != i+1 {
+					t.Fatalf("drops are not each in exactly one cluster: %v", clusters)
+				}
+			}
+		})
+	}
+}
+
+func TestQuoteBatches(t *testing.T) {
+	// Each batch is quoted at the fee named after the longitude of its only drop; batches
+	// without a fee fail to quote.
+	quoteServer := func(fees map[string]string) *Client {
+		return testClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			var body struct {
+				Stops []Waypoint `json:"stops"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			fee, ok := fees[body.Stops[1].Location.Lng]
+			if !ok {
+				return stubResponse(req, http.StatusInternalServerError, time.Time{}, ""), nil
+			}
+			return stubResponse(req, http.StatusOK, time.Time{}, fee), nil
+		}))
+	}
+	plan := &SplitPlan{Batches: []OrderBatch{
+		{Request: lineRequest(1), Drops: []int{1}},
+		{Request: lineRequest(2), Drops: []int{2}},
+		{Request: lineRequest(3), Drops: []int{3}},
+	}}
+	fee := func(amount, currency string) string {
+		return fmt.Sprintf(`{"totalFee":%q,"totalFeeCurrency":%q}`, amount, currency)
+	}
+
+	c := quoteServer(map[string]string{"121.0100": fee("90.50", "PHP"), "121.0300": fee("120", "PHP")})
+	group, err := c.QuoteBatches(context.Background(), CityCodePhilippinesManila, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Price{Amount: "210.50", Currency: "PHP"}); group.Total != want {
+		t.Errorf("Total = %v, want %v", group.Total, want)
+	}
+	if group.Quotations[1].Err == nil || group.Quotations[0].Err != nil || group.Quotations[2].Err != nil {
+		t.Errorf("only the second batch should fail to quote: %+v", group.Quotations)
+	}
+	if group.Err() == nil {
+		t.Error("Err() = nil with a batch that failed to quote")
+	}
+
+	c = quoteServer(map[string]string{"121.0100": fee("90.50", "PHP"), "121.0200": fee("12", "SGD"), "121.0300": fee("120", "PHP")})
+	group, err = c.QuoteBatches(context.Background(), CityCodePhilippinesManila, plan)
+	if !errors.Is(err, errInvalidCurrency) {
+		t.Fatalf("QuoteBatches() with mixed currencies error = %v, want %v", err, errInvalidCurrency)
+	}
+	if group == nil || len(group.Quotations) != 3 {
+		t.Fatalf("QuoteBatches() with mixed currencies returned %+v, want the per-batch quotations", group)
+	}
+	for i, bq := range group.Quotations {
+		if bq.Err != nil || bq.Quotation == nil {
+			t.Errorf("quotation %d = %+v, want a successful quotation", i, bq)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package lalamove
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrIllegalTransition is matched by errors.Is for status changes the state machine does not allow.
+	ErrIllegalTransition = errors.New("illegal order status transition")
+	// ErrStaleTransition is matched by errors.Is for status changes older than the latest one seen.
+	ErrStaleTransition = errors.New("stale order status transition")
+)
+
+// Transition is a change of status of an order.
+type Transition struct {
+	OrderID string      `json:"orderId"`
+	From    OrderStatus `json:"from,omitempty"`
+	To      OrderStatus `json:"to"`
+	// At is when the status changed according to the source of the update.
+	At time.Time `json:"at"`
+	// ObservedAt is when the change was received locally.
+	ObservedAt time.Time `json:"observedAt"`
+	// Inferred is set on transitions which were not observed but must have happened in between.
+	Inferred bool `json:"inferred,omitempty"`
+}
+
+// TransitionError describes a rejected Transition.
+type TransitionError struct {
+	Transition Transition
+	Err        error
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("order %s: %s -> %s: %s", e.Transition.OrderID, e.Transition.From, e.Transition.To, e.Err)
+}
+
+// Unwrap returns ErrIllegalTransition or ErrStaleTransition.
+func (e *TransitionError) Unwrap() error {
+	return e.Err
+}
+
+// OrderStateMachine describes the legal changes of OrderStatus.
+type OrderStateMachine struct {
+	transitions map[OrderStatus][]OrderStatus
+	cancellable map[OrderStatus]bool
+}
+
+// NewOrderStateMachine constructs a state machine from the statuses reachable from each status and
+// the statuses in which CancelOrder is accepted. Statuses without transitions are terminal.
+func NewOrderStateMachine(transitions map[OrderStatus][]OrderStatus, cancellable []OrderStatus) *OrderStateMachine {
+	m := &OrderStateMachine{transitions: map[OrderStatus][]OrderStatus{}, cancellable: map[OrderStatus]bool{}}
+	for from, tos := range transitions {
+		m.transitions[from] = append([]OrderStatus(nil), tos...)
+	}
+	for _, status := range cancellable {
+		m.cancellable[status] = true
+	}
+	return m
+}
+
+// DefaultOrderStateMachine follows the order lifecycle of the Lalamove APIs. An ON_GOING order goes
+// back to ASSIGNING_DRIVER when the driver is changed.
+var DefaultOrderStateMachine = NewOrderStateMachine(
+	map[OrderStatus][]OrderStatus{
+		OrderStatusAssigningDriver: {OrderStatusOngoing, OrderStatusCanceled, OrderStatusExpired},
+		OrderStatusOngoing:         {OrderStatusPickedUp, OrderStatusAssigningDriver, OrderStatusCanceled, OrderStatusRejected},
+		OrderStatusPickedUp:        {OrderStatusCompleted},
+	},
+	[]OrderStatus{OrderStatusAssigningDriver, OrderStatusOngoing},
+)
+
+// CanTransition reports whether an order may go directly from one status to the other.
+func (m *OrderStateMachine) CanTransition(from, to OrderStatus) bool {
+	for _, next := range m.transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether no further status changes are possible.
+func (m *OrderStateMachine) IsTerminal(status OrderStatus) bool {
+	return len(m.transitions[status]) == 0
+}
+
+// Cancellable reports whether CancelOrder may be accepted in the status. The cancellation policy
+// may still forbid it, e.g. when the driver was assigned too long ago.
+func (m *OrderStateMachine) Cancellable(status OrderStatus) bool {
+	return m.cancellable[status]
+}
+
+// Path returns the shortest sequence of statuses leading from one status to the other, excluding
+// from and including to, or false if to cannot be reached.
+func (m *OrderStateMachine) Path(from, to OrderStatus) ([]OrderStatus, bool) {
+	prev := map[OrderStatus]OrderStatus{from: from}
+	queue := []OrderStatus{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, next := range m.transitions[cur] {
+			if _, seen := prev[next]; seen {
+				continue
+			}
+			prev[next] = cur
+			if next == to {
+				var path []OrderStatus
+				for s := to; s != from; s = prev[s] {
+					path = append([]OrderStatus{s}, path...)
+				}
+				return path, true
+			}
+			queue = append(queue, next)
+		}
+	}
+	return nil, false
+}
+
+// Validate returns a TransitionError if the transition is not allowed. Transitions without a From
+// status, i.e. the first status seen for an order, are always allowed.
+func (m *OrderStateMachine) Validate(t Transition) error {
+	if t.From == "" || m.CanTransition(t.From, t.To) {
+		return nil
+	}
+	return &TransitionError{Transition: t, Err: ErrIllegalTransition}
+}
+
+// OrderTracker follows the status of many orders, as reported by pollers or webhooks, and turns
+// each update into the transitions it implies.
+type OrderTracker struct {
+	machine *OrderStateMachine
+	mu      sync.Mutex
+	latest  map[string]Transition
+}
+
+// NewOrderTracker constructs an OrderTracker. A nil machine uses DefaultOrderStateMachine.
+func NewOrderTracker(machine *OrderStateMachine) *OrderTracker {
+	if machine == nil {
+		machine = DefaultOrderStateMachine
+	}
+	return &OrderTracker{machine: machine, latest: map[string]Transition{}}
+}
+
+// Observe records that the order had the status at the given time and returns the transitions
+// this implies, oldest first:
+//   - nothing if the status did not change;
+//   - a single transition if the change is legal;
+//   - inferred transitions followed by the observed one if intermediate statuses were missed;
+//   - a TransitionError wrapping ErrStaleTransition if an update newer than at was already observed;
+//   - a TransitionError wrapping ErrIllegalTransition if the status cannot follow the latest one.
+func (t *OrderTracker) Observe(orderID string, status OrderStatus, at, observedAt time.Time) ([]Transition, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	latest, ok := t.latest[orderID]
+	next := Transition{OrderID: orderID, From: latest.To, To: status, At: at, ObservedAt: observedAt}
+	if ok && latest.To == status {
+		return nil, nil
+	}
+	if ok && at.Before(latest.At) {
+		return nil, &TransitionError{Transition: next, Err: ErrStaleTransition}
+	}
+	if !ok || t.machine.CanTransition(latest.To, status) {
+		t.latest[orderID] = next
+		return []Transition{next}, nil
+	}
+	path, reachable := t.machine.Path(latest.To, status)
+	if !reachable {
+		return nil, &TransitionError{Transition: next, Err: ErrIllegalTransition}
+	}
+	transitions := make([]Transition, 0, len(path))
+	from := latest.To
+	for _, to := range path {
+		transitions = append(transitions, Transition{OrderID: orderID, From: from, To: to, At: at, ObservedAt: observedAt, Inferred: to != status})
+		from = to
+	}
+	t.latest[orderID] = transitions[len(transitions)-1]
+	return transitions, nil
+}
+
+// Latest returns the latest transition of the order.
+func (t *OrderTracker) Latest(orderID string) (Transition, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	latest, ok := t.latest[orderID]
+	return latest, ok
+}
+
+// Forget stops tracking the order, e.g. once it reached a terminal status.
+func (t *OrderTracker) Forget(orderID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.latest, orderID)
+}
@@ -0,0 +1,146 @@
+package lalamove
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOrderStateMachine(t *testing.T) {
+	m := DefaultOrderStateMachine
+	if !m.CanTransition(OrderStatusOngoing, OrderStatusAssigningDriver) || m.CanTransition(OrderStatusPickedUp, OrderStatusCanceled) {
+		t.Error("CanTransition does not follow the order lifecycle")
+	}
+	for _, status := range []OrderStatus{OrderStatusCompleted, OrderStatusCanceled, OrderStatusRejected, OrderStatusExpired} {
+		if !m.IsTerminal(status) || m.Cancellable(status) {
+			t.Errorf("%s should be terminal and not cancellable", status)
+		}
+	}
+	if m.IsTerminal(OrderStatusPickedUp) || m.Cancellable(OrderStatusPickedUp) || !m.Cancellable(OrderStatusOngoing) {
+		t.Error("only orders waiting for or going to the pickup should be cancellable")
+	}
+
+	paths := []struct {
+		from, to OrderStatus
+		want     []OrderStatus
+		ok       bool
+	}{
+		{OrderStatusAssigningDriver, OrderStatusCompleted, []OrderStatus{OrderStatusOngoing, OrderStatusPickedUp, OrderStatusCompleted}, true},
+		{OrderStatusAssigningDriver, OrderStatusRejected, []OrderStatus{OrderStatusOngoing, OrderStatusRejected}, true},
+		{OrderStatusPickedUp, OrderStatusCanceled, nil, false},
+	}
+	for _, tt := range paths {
+		got, ok := m.Path(tt.from, tt.to)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Path(%s, %s) = %v, %t, want %v, %t", tt.from, tt.to, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if err := m.Validate(Transition{To: OrderStatusCompleted}); err != nil {
+		t.Errorf("Validate() of a first status = %v", err)
+	}
+	var terr *TransitionError
+	if err := m.Validate(Transition{OrderID: "1001", From: OrderStatusCompleted, To: OrderStatusOngoing}); !errors.As(err, &terr) || !errors.Is(err, ErrIllegalTransition) {
+		t.Errorf("Validate() of an illegal transition = %v, want a TransitionError wrapping %v", err, ErrIllegalTransition)
+	}
+}
+
+func TestOrderTrackerObserve(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	type observation struct {
+		status OrderStatus
+		at     time.Duration
+	}
+	type step struct {
+		from, to OrderStatus
+		at       time.Duration
+		inferred bool
+	}
+	tests := []struct {
+		name    string
+		seen    []observation
+		next    observation
+		want    []step
+		wantErr error
+	}{
+		{
+			name: "first status",
+			next: observation{OrderStatusAssigningDriver, 0},
+			want: []step{{to: OrderStatusAssigningDriver}},
+		},
+		{
+			name: "legal change",
+			seen: []observation{{OrderStatusAssigningDriver, 0}},
+			next: observation{OrderStatusOngoing, time.Minute},
+			want: []step{{OrderStatusAssigningDriver, OrderStatusOngoing, time.Minute, false}},
+		},
+		{
+			name: "same status",
+			seen: []observation{{OrderStatusAssigningDriver, 0}},
+			next: observation{OrderStatusAssigningDriver, time.Minute},
+		},
+		{
+			name: "missed statuses are inferred",
+			seen: []observation{{OrderStatusAssigningDriver, 0}},
+			next: observation{OrderStatusCompleted, time.Hour},
+			want: []step{
+				{OrderStatusAssigningDriver, OrderStatusOngoing, time.Hour, true},
+				{OrderStatusOngoing, OrderStatusPickedUp, time.Hour, true},
+				{OrderStatusPickedUp, OrderStatusCompleted, time.Hour, false},
+			},
+		},
+		{
+			name: "driver changed",
+			seen: []observation{{OrderStatusAssigningDriver, 0}, {OrderStatusOngoing, time.Minute}},
+			next: observation{OrderStatusAssigningDriver, 2 * time.Minute},
+			want: []step{{OrderStatusOngoing, OrderStatusAssigningDriver, 2 * time.Minute, false}},
+		},
+		{
+			name:    "stale",
+			seen:    []observation{{OrderStatusAssigningDriver, 0}, {OrderStatusOngoing, time.Minute}},
+			next:    observation{OrderStatusCanceled, 30 * time.Second},
+			wantErr: ErrStaleTransition,
+		},
+		{
+			name:    "illegal",
+			seen:    []observation{{OrderStatusAssigningDriver, 0}, {OrderStatusCompleted, time.Hour}},
+			next:    observation{OrderStatusCanceled, 2 * time.Hour},
+			wantErr: ErrIllegalTransition,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewOrderTracker(nil)
+			for _, o := range tt.seen {
+				if _, err := tracker.Observe("1001", o.status, t0.Add(o.at), t0.Add(o.at)); err != nil {
+					t.Fatalf("Observe(%s): %v", o.status, err)
+				}
+			}
+			observedAt := t0.Add(tt.next.at + time.Second)
+			got, err := tracker.Observe("1001", tt.next.status, t0.Add(tt.next.at), observedAt)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Observe(%s) error = %v, want %v", tt.next.status, err, tt.wantErr)
+			}
+			var want []Transition
+			for _, w := range tt.want {
+				want = append(want, Transition{OrderID: "1001", From: w.from, To: w.to, At: t0.Add(w.at), ObservedAt: observedAt, Inferred: w.inferred})
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Observe(%s) = %+v, want %+v", tt.next.status, got, want)
+			}
+		})
+	}
+}
+
+func TestOrderTrackerForget(t *testing.T) {
+	tracker := NewOrderTracker(nil)
+	now := time.Now()
+	if _, err := tracker.Observe("1001", OrderStatusCompleted, now, now); err != nil {
+		t.Fatal(err)
+	}
+	tracker.Forget("1001")
+	if _, ok := tracker.Latest("1001"); ok {
+		t.Error("Latest after Forget found the order")
+	}
+}
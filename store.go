@@ -0,0 +1,358 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrOrderNotFound is returned by an OrderStore for orders it has no record of.
+var ErrOrderNotFound = errors.New("order not found")
+
+// OrderRecord is the local record of an order placed through a RecordingClient.
+type OrderRecord struct {
+	OrderID string   `json:"orderId"`
+	City    CityCode `json:"city"`
+	// Quotation is the request the order was quoted and placed with.
+	Quotation GetQuotationRequest `json:"quotation"`
+	// QuotedPrice is the quoted total fee the order was placed with.
+	QuotedPrice Price              `json:"quotedPrice"`
+	Placed      PlaceOrderResponse `json:"placed"`
+	// Status and DriverID are the latest observed values.
+	Status    OrderStatus `json:"status"`
+	DriverID  string      `json:"driverId,omitempty"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+	// Events are the observed status and driver changes, oldest first.
+	Events []OrderEvent `json:"events,omitempty"`
+}
+
+// OrderEvent is an observed change of status or driver of an order.
+type OrderEvent struct {
+	At       time.Time   `json:"at"`
+	Status   OrderStatus `json:"status"`
+	DriverID string      `json:"driverId,omitempty"`
+}
+
+// OrderQuery filters the orders returned by OrderStore.FindOrders. Zero fields do not filter.
+type OrderQuery struct {
+	// Statuses matches orders whose latest status is any of the given ones.
+	Statuses []OrderStatus
+	// CreatedFrom matches orders created at or after the given time.
+	CreatedFrom time.Time
+	// CreatedTo matches orders created before the given time.
+	CreatedTo time.Time
+}
+
+// OrderStore persists local records of orders.
+type OrderStore interface {
+	// CreateOrder stores a new order record.
+	CreateOrder(ctx context.Context, rec *OrderRecord) error
+	// AppendEvent adds an event to the order and makes it the latest status and driver.
+	// It returns ErrOrderNotFound if the order was never created.
+	AppendEvent(ctx context.Context, orderID string, ev OrderEvent) error
+	// GetOrder returns the order record or ErrOrderNotFound.
+	GetOrder(ctx context.Context, orderID string) (*OrderRecord, error)
+	// FindOrders returns the records matching the query, oldest first.
+	FindOrders(ctx context.Context, q OrderQuery) ([]*OrderRecord, error)
+}
+
+func (q OrderQuery) matches(rec *OrderRecord) bool {
+	if !q.CreatedFrom.IsZero() && rec.CreatedAt.Before(q.CreatedFrom) {
+		return false
+	}
+	if !q.CreatedTo.IsZero() && !rec.CreatedAt.Before(q.CreatedTo) {
+		return false
+	}
+	if len(q.Statuses) == 0 {
+		return true
+	}
+	for _, status := range q.Statuses {
+		if rec.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// apply makes the event the latest state of the record.
+func (rec *OrderRecord) apply(ev OrderEvent) {
+	rec.Events = append(rec.Events, ev)
+	rec.Status = ev.Status
+	rec.DriverID = ev.DriverID
+	rec.UpdatedAt = ev.At
+}
+
+// clone returns a deep copy of the record, so callers cannot mutate the stored one.
+func (rec *OrderRecord) clone() *OrderRecord {
+	c := *rec
+	c.Quotation = cloneQuotationRequest(rec.Quotation)
+	c.Events = append([]OrderEvent(nil), rec.Events...)
+	return &c
+}
+
+func cloneQuotationRequest(req GetQuotationRequest) GetQuotationRequest {
+	c := req
+	if req.Stops != nil {
+		c.Stops = make([]Waypoint, len(req.Stops))
+		for i, stop := range req.Stops {
+			c.Stops[i] = stop
+			if stop.Addresses != nil {
+				c.Stops[i].Addresses = make(AddressTranslations, len(stop.Addresses))
+				for locale, address := range stop.Addresses {
+					c.Stops[i].Addresses[locale] = address
+				}
+			}
+		}
+	}
+	if req.Deliveries != nil {
+		c.Deliveries = make([]DeliveryInfo, len(req.Deliveries))
+		for i, d := range req.Deliveries {
+			c.Deliveries[i] = d
+			if d.Remarks != nil {
+				remarks := *d.Remarks
+				c.Deliveries[i].Remarks = &remarks
+			}
+		}
+	}
+	if req.ScheduleAt != nil {
+		scheduleAt := *req.ScheduleAt
+		c.ScheduleAt = &scheduleAt
+	}
+	if req.SpecialRequests != nil {
+		specialRequests := append([]SpecialRequest(nil), (*req.SpecialRequests)...)
+		c.SpecialRequests = &specialRequests
+	}
+	return c
+}
+
+// MemoryOrderStore is an OrderStore kept in memory.
+type MemoryOrderStore struct {
+	mu     sync.RWMutex
+	orders map[string]*OrderRecord
+}
+
+// NewMemoryOrderStore constructs an empty MemoryOrderStore.
+func NewMemoryOrderStore() *MemoryOrderStore {
+	return &MemoryOrderStore{orders: map[string]*OrderRecord{}}
+}
+
+// snapshot returns a deep copy of the store.
+func (s *MemoryOrderStore) snapshot() *MemoryOrderStore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c := NewMemoryOrderStore()
+	for id, rec := range s.orders {
+		c.orders[id] = rec.clone()
+	}
+	return c
+}
+
+// CreateOrder implements OrderStore.
+func (s *MemoryOrderStore) CreateOrder(ctx context.Context, rec *OrderRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orders[rec.OrderID]; ok {
+		return fmt.Errorf("order %s already exists", rec.OrderID)
+	}
+	s.orders[rec.OrderID] = rec.clone()
+	return nil
+}
+
+// AppendEvent implements OrderStore.
+func (s *MemoryOrderStore) AppendEvent(ctx context.Context, orderID string, ev OrderEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.orders[orderID]
+	if !ok {
+		return ErrOrderNotFound
+	}
+	rec.apply(ev)
+	return nil
+}
+
+// GetOrder implements OrderStore.
+func (s *MemoryOrderStore) GetOrder(ctx context.Context, orderID string) (*OrderRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.orders[orderID]
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	return rec.clone(), nil
+}
+
+// FindOrders implements OrderStore.
+func (s *MemoryOrderStore) FindOrders(ctx context.Context, q OrderQuery) ([]*OrderRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var recs []*OrderRecord
+	for _, rec := range s.orders {
+		if q.matches(rec) {
+			recs = append(recs, rec.clone())
+		}
+	}
+	sort.Slice(recs, func(i, j int) bool {
+		return recs[i].CreatedAt.Before(recs[j].CreatedAt)
+	})
+	return recs, nil
+}
+
+// RecordingClient decorates a Client to record every placed order and every observed
+// status or driver change in an OrderStore. Every method of Client which places orders or
+// retrieves their details is overridden to record them.
+type RecordingClient struct {
+	*Client
+	Store OrderStore
+}
+
+// NewRecordingClient constructs a RecordingClient.
+func NewRecordingClient(c *Client, store OrderStore) *RecordingClient {
+	return &RecordingClient{Client: c, Store: store}
+}
+
+// PlaceOrder places the order and records it. If the order was placed but could not be recorded,
+// both the response and the error are returned. Orders placed with an UnknownEnumError in strict
+// mode are recorded too.
+func (r *RecordingClient) PlaceOrder(ctx context.Context, city CityCode, req *PlaceOrderRequest) (*PlaceOrderResponse, error) {
+	resp, err := r.Client.PlaceOrder(ctx, city, req)
+	if resp == nil {
+		return nil, err
+	}
+	if err := r.create(ctx, city, req, resp); err != nil {
+		return resp, err
+	}
+	return resp, err
+}
+
+// PlaceBatches places the batches as Client.PlaceBatches does and records every placed order.
+// Orders canceled because another batch failed are recorded as CANCELED. If any placed order
+// could not be recorded, the result is returned with an error naming it.
+func (r *RecordingClient) PlaceBatches(ctx context.Context, city CityCode, plan *SplitPlan, quotes *GroupQuotation, sendSms *bool, atomic bool) (*GroupOrder, error) {
+	group, err := r.Client.PlaceBatches(ctx, city, plan, quotes, sendSms, atomic)
+	if group == nil {
+		return nil, err
+	}
+	var recordErr error
+	for i, bo := range group.Orders {
+		if bo.Order == nil {
+			continue
+		}
+		quote := quotes.Quotations[i].Quotation
+		req := &PlaceOrderRequest{
+			QuotedPrice:         Price{Amount: quote.Amount, Currency: quote.Currency},
+			SendSms:             sendSms,
+			GetQuotationRequest: *plan.Batches[i].Request,
+		}
+		rerr := r.create(ctx, city, req, bo.Order)
+		if rerr == nil && bo.Canceled {
+			rerr = r.Store.AppendEvent(ctx, bo.Order.OrderID, OrderEvent{At: r.now(), Status: OrderStatusCanceled})
+		}
+		if rerr != nil && recordErr == nil {
+			recordErr = rerr
+		}
+	}
+	if err == nil {
+		err = recordErr
+	}
+	return group, err
+}
+
+// OrderDetails retrieves the order and records its status and driver if either changed since the
+// last observation. Orders not placed through the RecordingClient are not recorded.
+func (r *RecordingClient) OrderDetails(ctx context.Context, city CityCode, orderID string) (*OrderDetailsResponse, error) {
+	resp, err := r.Client.OrderDetails(ctx, city, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return resp, r.observe(ctx, orderID, resp)
+}
+
+// EditOrder edits the order and records the status and driver of the returned details as
+// OrderDetails does.
+func (r *RecordingClient) EditOrder(ctx context.Context, city CityCode, orderID string, changes *EditOrderRequest) (*OrderDetailsResponse, error) {
+	resp, err := r.Client.EditOrder(ctx, city, orderID, changes)
+	if resp == nil {
+		return nil, err
+	}
+	if err := r.observe(ctx, orderID, resp); err != nil {
+		return resp, err
+	}
+	return resp, err
+}
+
+// BatchOrderDetails retrieves the orders as Client.BatchOrderDetails does and records the status
+// and driver of every order retrieved. An order which could not be recorded keeps its details and
+// reports the error in its result.
+func (r *RecordingClient) BatchOrderDetails(ctx context.Context, city CityCode, ids []string) []OrderDetailsResult {
+	results := r.Client.BatchOrderDetails(ctx, city, ids)
+	for i, res := range results {
+		if res.Err == nil && res.Details != nil {
+			results[i].Err = r.observe(ctx, res.OrderID, res.Details)
+		}
+	}
+	return results
+}
+
+// SafeCancel cancels the order as Client.SafeCancel does and records the status and driver of the
+// returned details. A recording error is only returned if the cancellation succeeded.
+func (r *RecordingClient) SafeCancel(ctx context.Context, city CityCode, orderID string, driverAssignedAt time.Time) (*OrderDetailsResponse, error) {
+	resp, err := r.Client.SafeCancel(ctx, city, orderID, driverAssignedAt)
+	if resp == nil {
+		return nil, err
+	}
+	if oerr := r.observe(ctx, orderID, resp); err == nil {
+		err = oerr
+	}
+	return resp, err
+}
+
+// EscalatePriorityFee escalates the priority fee as Client.EscalatePriorityFee does and records
+// the status and driver of the order details retrieved last. A recording error is only returned if
+// the escalation succeeded.
+func (r *RecordingClient) EscalatePriorityFee(ctx context.Context, city CityCode, orderID string, e PriorityFeeEscalation) (*EscalationResult, error) {
+	result, err := r.Client.EscalatePriorityFee(ctx, city, orderID, e)
+	if result == nil || result.Details == nil {
+		return result, err
+	}
+	if oerr := r.observe(ctx, orderID, result.Details); err == nil {
+		err = oerr
+	}
+	return result, err
+}
+
+// create records an order placed with req.
+func (r *RecordingClient) create(ctx context.Context, city CityCode, req *PlaceOrderRequest, resp *PlaceOrderResponse) error {
+	now := r.now()
+	rec := &OrderRecord{
+		OrderID:     resp.OrderID,
+		City:        city,
+		Quotation:   req.GetQuotationRequest,
+		QuotedPrice: req.QuotedPrice,
+		Placed:      *resp,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := r.Store.CreateOrder(ctx, rec); err != nil {
+		return fmt.Errorf("order %s placed but not recorded: %w", resp.OrderID, err)
+	}
+	return nil
+}
+
+// observe records the status and driver of the order details if either changed since the last
+// observation. Orders not placed through the RecordingClient are not recorded.
+func (r *RecordingClient) observe(ctx context.Context, orderID string, resp *OrderDetailsResponse) error {
+	rec, err := r.Store.GetOrder(ctx, orderID)
+	if err == ErrOrderNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if len(rec.Events) > 0 && rec.Status == resp.Status && rec.DriverID == resp.DriverID {
+		return nil
+	}
+	return r.Store.AppendEvent(ctx, orderID, OrderEvent{At: r.now(), Status: resp.Status, DriverID: resp.DriverID})
+}
@@ -0,0 +1,106 @@
+package lalamove
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileOrderStore is an OrderStore persisted as a single JSON file. The whole file is
+// rewritten on every change, so it suits low volumes and local tooling.
+type FileOrderStore struct {
+	path  string
+	mu    sync.Mutex
+	cache *MemoryOrderStore
+}
+
+// OpenFileOrderStore opens the JSON file at path, creating it on the first write if it does not exist.
+func OpenFileOrderStore(path string) (*FileOrderStore, error) {
+	s := &FileOrderStore{path: path, cache: NewMemoryOrderStore()}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) || (err == nil && len(b) == 0) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var recs []*OrderRecord
+	if err := json.Unmarshal(b, &recs); err != nil {
+		return nil, err
+	}
+	for _, rec := range recs {
+		s.cache.orders[rec.OrderID] = rec
+	}
+	return s, nil
+}
+
+// CreateOrder implements OrderStore.
+func (s *FileOrderStore) CreateOrder(ctx context.Context, rec *OrderRecord) error {
+	return s.update(ctx, func(next *MemoryOrderStore) error {
+		return next.CreateOrder(ctx, rec)
+	})
+}
+
+// AppendEvent implements OrderStore.
+func (s *FileOrderStore) AppendEvent(ctx context.Context, orderID string, ev OrderEvent) error {
+	return s.update(ctx, func(next *MemoryOrderStore) error {
+		return next.AppendEvent(ctx, orderID, ev)
+	})
+}
+
+// GetOrder implements OrderStore.
+func (s *FileOrderStore) GetOrder(ctx context.Context, orderID string) (*OrderRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.GetOrder(ctx, orderID)
+}
+
+// FindOrders implements OrderStore.
+func (s *FileOrderStore) FindOrders(ctx context.Context, q OrderQuery) ([]*OrderRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.FindOrders(ctx, q)
+}
+
+// update applies the change to a copy of the records and writes it to the file. The records in
+// memory are only replaced once the file was written, so a failed write leaves both unchanged.
+func (s *FileOrderStore) update(ctx context.Context, change func(next *MemoryOrderStore) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := s.cache.snapshot()
+	if err := change(next); err != nil {
+		return err
+	}
+	if err := s.flush(ctx, next); err != nil {
+		return err
+	}
+	s.cache = next
+	return nil
+}
+
+// flush atomically replaces the file with the given records.
+func (s *FileOrderStore) flush(ctx context.Context, records *MemoryOrderStore) error {
+	recs, err := records.FindOrders(ctx, OrderQuery{})
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(recs, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,231 @@
+package lalamove
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// sqlOrderSchema creates the tables of SQLOrderStore. Timestamps are stored as Unix nanoseconds
+// so that they sort and compare the same way on every database.
+var sqlOrderSchema = []string{
+	`CREATE TABLE IF NOT EXISTS lalamove_orders (
+		order_id          TEXT PRIMARY KEY,
+		city              TEXT NOT NULL,
+		quotation         TEXT NOT NULL,
+		quoted_amount     TEXT NOT NULL,
+		quoted_currency   TEXT NOT NULL,
+		customer_order_id TEXT NOT NULL,
+		status            TEXT NOT NULL,
+		driver_id         TEXT NOT NULL,
+		created_at        INTEGER NOT NULL,
+		updated_at        INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS lalamove_orders_status_created_at ON lalamove_orders (status, created_at)`,
+	`CREATE TABLE IF NOT EXISTS lalamove_order_events (
+		order_id  TEXT NOT NULL,
+		seq       INTEGER NOT NULL,
+		at        INTEGER NOT NULL,
+		status    TEXT NOT NULL,
+		driver_id TEXT NOT NULL,
+		PRIMARY KEY (order_id, seq)
+	)`,
+}
+
+// SQLOrderStore is an OrderStore backed by database/sql. Queries use "?" placeholders and
+// SQLite-compatible DDL; the database driver is chosen by the caller.
+// With SQLite, open the database with a busy timeout so that concurrent writers wait for each
+// other instead of failing.
+type SQLOrderStore struct {
+	db *sql.DB
+}
+
+// NewSQLOrderStore constructs a SQLOrderStore over db. Call Migrate once to create the tables.
+func NewSQLOrderStore(db *sql.DB) *SQLOrderStore {
+	return &SQLOrderStore{db: db}
+}
+
+// Migrate creates the tables and indexes used by the store if they do not exist.
+func (s *SQLOrderStore) Migrate(ctx context.Context) error {
+	for _, stmt := range sqlOrderSchema {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateOrder implements OrderStore.
+func (s *SQLOrderStore) CreateOrder(ctx context.Context, rec *OrderRecord) error {
+	quotation, err := json.Marshal(rec.Quotation)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, `INSERT INTO lalamove_orders
+		(order_id, city, quotation, quoted_amount, quoted_currency, customer_order_id, status, driver_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.OrderID, string(rec.City), string(quotation), rec.QuotedPrice.Amount, rec.QuotedPrice.Currency,
+		rec.Placed.CustomerOrderID, string(rec.Status), rec.DriverID, rec.CreatedAt.UnixNano(), rec.UpdatedAt.UnixNano())
+	if err != nil {
+		return err
+	}
+	for i, ev := range rec.Events {
+		if err := insertOrderEvent(ctx, tx, rec.OrderID, i, ev); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// sqlAppendAttempts bounds the attempts of AppendEvent when its transaction fails, e.g. when a
+// concurrent append took the same sequence number or the database was busy.
+const sqlAppendAttempts = 5
+
+// AppendEvent implements OrderStore. The event is numbered after the latest one of the order in the
+// same statement that inserts it, and the transaction is retried if it fails.
+func (s *SQLOrderStore) AppendEvent(ctx context.Context, orderID string, ev OrderEvent) error {
+	var err error
+	for attempt := 0; attempt < sqlAppendAttempts; attempt++ {
+		if err = s.appendEvent(ctx, orderID, ev); err == nil || err == ErrOrderNotFound || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *SQLOrderStore) appendEvent(ctx context.Context, orderID string, ev OrderEvent) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, `UPDATE lalamove_orders SET status = ?, driver_id = ?, updated_at = ? WHERE order_id = ?`,
+		string(ev.Status), ev.DriverID, ev.At.UnixNano(), orderID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrOrderNotFound
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO lalamove_order_events (order_id, seq, at, status, driver_id)
+		SELECT ?, COALESCE(MAX(seq), -1) + 1, ?, ?, ? FROM lalamove_order_events WHERE order_id = ?`,
+		orderID, ev.At.UnixNano(), string(ev.Status), ev.DriverID, orderID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetOrder implements OrderStore.
+func (s *SQLOrderStore) GetOrder(ctx context.Context, orderID string) (*OrderRecord, error) {
+	recs, err := s.queryOrders(ctx, `WHERE order_id = ?`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 {
+		return nil, ErrOrderNotFound
+	}
+	return recs[0], nil
+}
+
+// FindOrders implements OrderStore.
+func (s *SQLOrderStore) FindOrders(ctx context.Context, q OrderQuery) ([]*OrderRecord, error) {
+	var conds []string
+	var args []interface{}
+	if len(q.Statuses) > 0 {
+		placeholders := make([]string, len(q.Statuses))
+		for i, status := range q.Statuses {
+			placeholders[i] = "?"
+			args = append(args, string(status))
+		}
+		conds = append(conds, "status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if !q.CreatedFrom.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, q.CreatedFrom.UnixNano())
+	}
+	if !q.CreatedTo.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, q.CreatedTo.UnixNano())
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+	return s.queryOrders(ctx, where, args...)
+}
+
+func (s *SQLOrderStore) queryOrders(ctx context.Context, where string, args ...interface{}) ([]*OrderRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT order_id, city, quotation, quoted_amount, quoted_currency,
+		customer_order_id, status, driver_id, created_at, updated_at
+		FROM lalamove_orders `+where+` ORDER BY created_at, order_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var recs []*OrderRecord
+	for rows.Next() {
+		rec := &OrderRecord{}
+		var city, quotation, status string
+		var createdAt, updatedAt int64
+		if err := rows.Scan(&rec.OrderID, &city, &quotation, &rec.QuotedPrice.Amount, &rec.QuotedPrice.Currency,
+			&rec.Placed.CustomerOrderID, &status, &rec.DriverID, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(quotation), &rec.Quotation); err != nil {
+			return nil, err
+		}
+		rec.City = CityCode(city)
+		rec.Status = OrderStatus(status)
+		rec.Placed.OrderID = rec.OrderID
+		rec.CreatedAt = time.Unix(0, createdAt).UTC()
+		rec.UpdatedAt = time.Unix(0, updatedAt).UTC()
+		recs = append(recs, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, rec := range recs {
+		if rec.Events, err = s.queryEvents(ctx, rec.OrderID); err != nil {
+			return nil, err
+		}
+	}
+	return recs, nil
+}
+
+func (s *SQLOrderStore) queryEvents(ctx context.Context, orderID string) ([]OrderEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT at, status, driver_id FROM lalamove_order_events
+		WHERE order_id = ? ORDER BY seq`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []OrderEvent
+	for rows.Next() {
+		var at int64
+		var status string
+		ev := OrderEvent{}
+		if err := rows.Scan(&at, &status, &ev.DriverID); err != nil {
+			return nil, err
+		}
+		ev.At = time.Unix(0, at).UTC()
+		ev.Status = OrderStatus(status)
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+func insertOrderEvent(ctx context.Context, tx *sql.Tx, orderID string, seq int, ev OrderEvent) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO lalamove_order_events (order_id, seq, at, status, driver_id) VALUES (?, ?, ?, ?, ?)`,
+		orderID, seq, ev.At.UnixNano(), string(ev.Status), ev.DriverID)
+	return err
+}
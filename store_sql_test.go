@@ -0,0 +1,47 @@
+//go:build cgo
+// +build cgo
+
+package lalamove
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// openTestDB opens a SQLite database with the tables of SQLOrderStore.
+func openTestDB(t *testing.T, dsn string) *SQLOrderStore {
+	t.Helper()
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store := NewSQLOrderStore(db)
+	if err := store.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestSQLOrderStore(t *testing.T) {
+	// An in-memory database lives as long as its connection, so the pool is kept to one.
+	store := openTestDB(t, fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
+	store.db.SetMaxOpenConns(1)
+	testOrderStore(t, store)
+
+	if err := store.Migrate(context.Background()); err != nil {
+		t.Errorf("second Migrate() error = %v", err)
+	}
+}
+
+func TestSQLOrderStoreConcurrentConnections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.db")
+	store := openTestDB(t, "file:"+path+"?_busy_timeout=5000&_txlock=immediate")
+	store.db.SetMaxOpenConns(8)
+	testOrderStore(t, store)
+}
@@ -0,0 +1,391 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServerClient constructs a client of a FakeServer playing the scenario, both reading the time
+// from now.
+func fakeServerClient(t *testing.T, s *Scenario, now *time.Time, options ...ClientOption) *Client {
+	t.Helper()
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	fake := NewFakeServer(s)
+	var mu sync.Mutex
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return *now
+	}
+	fake.Now = clock
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	c, err := NewClient(append([]ClientOption{
+		WithBaseURL(srv.URL),
+		WithAPIKey(s.APIKey),
+		WithSecret(s.Secret),
+		WithClock(clock),
+	}, options...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// testScenario is a scenario whose orders are assigned a driver after a minute and completed after
+// an hour.
+func testScenario() *Scenario {
+	return &Scenario{
+		APIKey:    "pk_test_fake",
+		Secret:    "sk_test_fake",
+		Quotation: GetQuotationResponse{Amount: "163.00", Currency: "PHP"},
+		Order: ScenarioOrder{
+			OrderID: "1001",
+			Timeline: []ScenarioStep{
+				{After: ScenarioDuration(time.Minute), Status: OrderStatusOngoing},
+				{After: ScenarioDuration(30 * time.Minute), Status: OrderStatusPickedUp},
+				{After: ScenarioDuration(time.Hour), Status: OrderStatusCompleted},
+			},
+		},
+		Driver: ScenarioDriver{ID: "21712"},
+	}
+}
+
+func testOrderRecord(id string, createdAt time.Time) *OrderRecord {
+	return &OrderRecord{
+		OrderID:     id,
+		City:        CityCodePhilippinesManila,
+		Quotation:   *testQuotation(),
+		QuotedPrice: Price{Amount: "163.00", Currency: "PHP"},
+		Placed:      PlaceOrderResponse{OrderID: id, CustomerOrderID: "c-" + id},
+		Status:      OrderStatusAssigningDriver,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}
+}
+
+// testOrderStore checks the behavior every OrderStore shares.
+func testOrderStore(t *testing.T, store OrderStore) {
+	ctx := context.Background()
+	first := testOrderRecord("1001", testNow)
+	second := testOrderRecord("1002", testNow.Add(time.Hour))
+	for _, rec := range []*OrderRecord{second, first} {
+		if err := store.CreateOrder(ctx, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.CreateOrder(ctx, testOrderRecord("1001", testNow)); err == nil {
+		t.Error("order created twice")
+	}
+
+	if _, err := store.GetOrder(ctx, "1003"); err != ErrOrderNotFound {
+		t.Errorf("GetOrder of unknown order error = %v, want ErrOrderNotFound", err)
+	}
+	if err := store.AppendEvent(ctx, "1003", OrderEvent{At: testNow, Status: OrderStatusOngoing}); err != ErrOrderNotFound {
+		t.Errorf("AppendEvent to unknown order error = %v, want ErrOrderNotFound", err)
+	}
+
+	assigned := OrderEvent{At: testNow.Add(time.Minute), Status: OrderStatusOngoing, DriverID: "21712"}
+	if err := store.AppendEvent(ctx, "1001", assigned); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.GetOrder(ctx, "1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := testOrderRecord("1001", testNow)
+	want.Status, want.DriverID, want.UpdatedAt = assigned.Status, assigned.DriverID, assigned.At
+	want.Events = []OrderEvent{assigned}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetOrder() = %+v, want %+v", got, want)
+	}
+
+	// Records returned are copies.
+	got.Status = OrderStatusCanceled
+	got.Events[0].DriverID = "0"
+	got.Quotation.Stops[0].Addresses[LocalePhilippinesEN] = Address{}
+	if again, _ := store.GetOrder(ctx, "1001"); !reflect.DeepEqual(again, want) {
+		t.Errorf("record changed through a returned copy: %+v", again)
+	}
+
+	tests := []struct {
+		name  string
+		query OrderQuery
+		want  []string
+	}{
+		{"all, oldest first", OrderQuery{}, []string{"1001", "1002"}},
+		{"by status", OrderQuery{Statuses: []OrderStatus{OrderStatusAssigningDriver}}, []string{"1002"}},
+		{"by any status", OrderQuery{Statuses: []OrderStatus{OrderStatusOngoing, OrderStatusAssigningDriver}}, []string{"1001", "1002"}},
+		{"created from", OrderQuery{CreatedFrom: testNow.Add(time.Hour)}, []string{"1002"}},
+		{"created to", OrderQuery{CreatedTo: testNow.Add(time.Hour)}, []string{"1001"}},
+		{"no match", OrderQuery{Statuses: []OrderStatus{OrderStatusCompleted}}, nil},
+	}
+	for _, tt := range tests {
+		recs, err := store.FindOrders(ctx, tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, rec := range recs {
+			ids = append(ids, rec.OrderID)
+		}
+		if !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("FindOrders(%s) = %v, want %v", tt.name, ids, tt.want)
+		}
+	}
+
+	// Concurrent appends are all kept, in a consistent order.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ev := OrderEvent{At: testNow.Add(time.Duration(i) * time.Second), Status: OrderStatusAssigningDriver, DriverID: fmt.Sprint(i)}
+			if err := store.AppendEvent(ctx, "1002", ev); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	rec, err := store.GetOrder(ctx, "1002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Events) != 20 {
+		t.Fatalf("events after concurrent appends = %d, want 20", len(rec.Events))
+	}
+	seen := map[string]bool{}
+	for _, ev := range rec.Events {
+		seen[ev.DriverID] = true
+	}
+	if len(seen) != 20 {
+		t.Errorf("distinct events = %d, want 20", len(seen))
+	}
+}
+
+func TestMemoryOrderStore(t *testing.T) {
+	testOrderStore(t, NewMemoryOrderStore())
+}
+
+func TestFileOrderStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.json")
+	store, err := OpenFileOrderStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testOrderStore(t, store)
+
+	reopened, err := OpenFileOrderStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	want, _ := store.FindOrders(ctx, OrderQuery{})
+	got, err := reopened.FindOrders(ctx, OrderQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reopened records = %+v, want %+v", got, want)
+	}
+}
+
+func TestRecordingClient(t *testing.T) {
+	ctx := context.Background()
+	now := testNow
+	store := NewMemoryOrderStore()
+	r := NewRecordingClient(fakeServerClient(t, testScenario(), &now), store)
+	place := func() string {
+		t.Helper()
+		req := testQuotation()
+		quote, err := r.GetQuotation(ctx, CityCodePhilippinesManila, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		placed, err := r.PlaceOrder(ctx, CityCodePhilippinesManila, &PlaceOrderRequest{
+			QuotedPrice:         Price{Amount: quote.Amount, Currency: quote.Currency},
+			GetQuotationRequest: *req,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return placed.OrderID
+	}
+	statuses := func(orderID string) []OrderStatus {
+		t.Helper()
+		rec, err := store.GetOrder(ctx, orderID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var statuses []OrderStatus
+		for _, ev := range rec.Events {
+			statuses = append(statuses, ev.Status)
+		}
+		return statuses
+	}
+
+	t.Run("PlaceOrder and OrderDetails", func(t *testing.T) {
+		id := place()
+		rec, err := store.GetOrder(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.QuotedPrice.Amount != "163.00" || rec.City != CityCodePhilippinesManila || !rec.CreatedAt.Equal(now) {
+			t.Errorf("record = %+v, want the placed order", rec)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := r.OrderDetails(ctx, CityCodePhilippinesManila, id); err != nil {
+				t.Fatal(err)
+			}
+		}
+		now = now.Add(2 * time.Minute)
+		if _, err := r.OrderDetails(ctx, CityCodePhilippinesManila, id); err != nil {
+			t.Fatal(err)
+		}
+		want := []OrderStatus{OrderStatusAssigningDriver, OrderStatusOngoing}
+		if got := statuses(id); !reflect.DeepEqual(got, want) {
+			t.Errorf("recorded statuses = %v, want %v", got, want)
+		}
+		if rec, _ := store.GetOrder(ctx, id); rec.DriverID != "21712" {
+			t.Errorf("recorded driver = %q, want 21712", rec.DriverID)
+		}
+	})
+
+	t.Run("BatchOrderDetails", func(t *testing.T) {
+		ids := []string{place(), place()}
+		for _, res := range r.BatchOrderDetails(ctx, CityCodePhilippinesManila, ids) {
+			if res.Err != nil {
+				t.Fatal(res.Err)
+			}
+		}
+		for _, id := range ids {
+			if got := statuses(id); !reflect.DeepEqual(got, []OrderStatus{OrderStatusAssigningDriver}) {
+				t.Errorf("recorded statuses of %s = %v, want ASSIGNING_DRIVER", id, got)
+			}
+		}
+	})
+
+	t.Run("SafeCancel", func(t *testing.T) {
+		id := place()
+		if _, err := r.SafeCancel(ctx, CityCodePhilippinesManila, id, time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+		if got := statuses(id); !reflect.DeepEqual(got, []OrderStatus{OrderStatusCanceled}) {
+			t.Errorf("recorded statuses = %v, want CANCELED", got)
+		}
+	})
+
+	t.Run("EscalatePriorityFee", func(t *testing.T) {
+		id := place()
+		php := func(amount string) Money { return Money{Amount: amount, Currency: "PHP"} }
+		if _, err := r.EscalatePriorityFee(ctx, CityCodePhilippinesManila, id, PriorityFeeEscalation{Step: php("10"), Cap: php("10"), Interval: time.Millisecond}); err != nil {
+			t.Fatal(err)
+		}
+		if got := statuses(id); !reflect.DeepEqual(got, []OrderStatus{OrderStatusAssigningDriver}) {
+			t.Errorf("recorded statuses = %v, want ASSIGNING_DRIVER", got)
+		}
+	})
+
+	t.Run("EditOrder", func(t *testing.T) {
+		id := place()
+		if _, err := r.EditOrder(ctx, CityCodePhilippinesManila, id, &EditOrderRequest{
+			RequesterContact: &Contact{Name: "Maria Santos", Phone: "+639179876543"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if got := statuses(id); !reflect.DeepEqual(got, []OrderStatus{OrderStatusAssigningDriver}) {
+			t.Errorf("recorded statuses = %v, want ASSIGNING_DRIVER", got)
+		}
+	})
+
+	t.Run("orders placed elsewhere", func(t *testing.T) {
+		id := place()
+		other := NewRecordingClient(r.Client, NewMemoryOrderStore())
+		if _, err := other.OrderDetails(ctx, CityCodePhilippinesManila, id); err != nil {
+			t.Errorf("error = %v, want orders placed elsewhere to be ignored", err)
+		}
+	})
+}
+
+func TestRecordingClientPlaceBatches(t *testing.T) {
+	ctx := context.Background()
+	now := testNow
+	store := NewMemoryOrderStore()
+	// The second order placed fails.
+	placed := 0
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodPost && req.URL.Path == "/v2/orders" {
+			if placed++; placed == 2 {
+				return stubResponse(req, http.StatusInternalServerError, time.Time{}, ""), nil
+			}
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	r := NewRecordingClient(fakeServerClient(t, testScenario(), &now, WithHTTPClient(&http.Client{Transport: transport})), store)
+
+	req := testQuotation()
+	plan := &SplitPlan{Batches: []OrderBatch{{Request: req}, {Request: req}}}
+	quotes, err := r.QuoteBatches(ctx, CityCodePhilippinesManila, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := r.PlaceBatches(ctx, CityCodePhilippinesManila, plan, quotes, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.Orders[0].Order == nil || !group.Orders[0].Canceled || group.Orders[1].Err == nil {
+		t.Fatalf("orders = %+v, want the first placed and canceled after the second failed", group.Orders)
+	}
+	rec, err := store.GetOrder(ctx, group.Orders[0].Order.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Status != OrderStatusCanceled {
+		t.Errorf("recorded status = %s, want CANCELED", rec.Status)
+	}
+	if recs, _ := store.FindOrders(ctx, OrderQuery{}); len(recs) != 1 {
+		t.Errorf("recorded orders = %d, want 1", len(recs))
+	}
+}
+
+func TestRecordingClientReportsStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	now := testNow
+	r := NewRecordingClient(fakeServerClient(t, testScenario(), &now), failingStore{})
+	req := testQuotation()
+	resp, err := r.PlaceOrder(ctx, CityCodePhilippinesManila, &PlaceOrderRequest{
+		QuotedPrice:         Price{Amount: "163.00", Currency: "PHP"},
+		GetQuotationRequest: *req,
+	})
+	if resp == nil || !errors.Is(err, errStoreUnavailable) {
+		t.Errorf("PlaceOrder() = %v, %v, want the placed order and the store error", resp, err)
+	}
+}
+
+var errStoreUnavailable = errors.New("store unavailable")
+
+// failingStore is an OrderStore failing every call.
+type failingStore struct{}
+
+func (failingStore) CreateOrder(ctx context.Context, rec *OrderRecord) error {
+	return errStoreUnavailable
+}
+func (failingStore) AppendEvent(ctx context.Context, orderID string, ev OrderEvent) error {
+	return errStoreUnavailable
+}
+func (failingStore) GetOrder(ctx context.Context, orderID string) (*OrderRecord, error) {
+	return nil, errStoreUnavailable
+}
+func (failingStore) FindOrders(ctx context.Context, q OrderQuery) ([]*OrderRecord, error) {
+	return nil, errStoreUnavailable
+}
@@ -0,0 +1,142 @@
+package lalamove
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxWebhookURLLength is the longest webhook URL accepted.
+const maxWebhookURLLength = 2048
+
+// minStops and maxStops bound the number of stops of a request, pickup included.
+const (
+	minStops = 2
+	maxStops = 10
+)
+
+// Validate checks the request against the rules enforced by the Lalamove APIs, so that malformed
+// requests fail before they are sent. A schedule is checked against the local clock; use ValidateAt
+// to check it against another one.
+func (r *GetQuotationRequest) Validate() error {
+	return r.ValidateAt(time.Now())
+}
+
+// ValidateAt checks the request like Validate, rejecting a schedule before now.
+func (r *GetQuotationRequest) ValidateAt(now time.Time) error {
+	if r.ServiceType == "" {
+		return fmt.Errorf("serviceType: %w", errRequiredField)
+	}
+	if err := validateStops(r.Stops); err != nil {
+		return err
+	}
+	if err := validateDeliveries(r.Deliveries, len(r.Stops)); err != nil {
+		return err
+	}
+	if err := validateContact("requesterContact", r.RequesterContact); err != nil {
+		return err
+	}
+	if r.ScheduleAt != nil {
+		at, err := time.Parse(time.RFC3339, *r.ScheduleAt)
+		if err != nil {
+			return fmt.Errorf("scheduleAt: %w", errInvalidParams)
+		}
+		if at.Before(now) {
+			return fmt.Errorf("scheduleAt: %w", errInvalidScheduleTime)
+		}
+	}
+	return nil
+}
+
+func validateStops(stops []Waypoint) error {
+	if len(stops) < minStops {
+		return errInsufficientStops
+	}
+	if len(stops) > maxStops {
+		return errTooManyStops
+	}
+	for i, stop := range stops {
+		if _, err := stop.Location.LatLng(); err != nil {
+			return fmt.Errorf("stops[%d].location: %s: %w", i, err, errInvalidParams)
+		}
+		if len(stop.Addresses) == 0 {
+			return fmt.Errorf("stops[%d].addresses: %w", i, errRequiredField)
+		}
+		for locale, addr := range stop.Addresses {
+			if strings.TrimSpace(addr.DisplayString) == "" {
+				return fmt.Errorf("stops[%d].addresses.%s.displayString: %w", i, locale, errRequiredField)
+			}
+			if addr.Country == "" {
+				return fmt.Errorf("stops[%d].addresses.%s.country: %w", i, locale, errRequiredField)
+			}
+		}
+	}
+	return nil
+}
+
+func validateDeliveries(deliveries []DeliveryInfo, stops int) error {
+	if len(deliveries) == 0 {
+		return fmt.Errorf("deliveries: %w", errRequiredField)
+	}
+	for i, d := range deliveries {
+		if d.ToStop < 1 || d.ToStop >= int64(stops) {
+			return fmt.Errorf("deliveries[%d].toStop: %w", i, errDeliveryMismatch)
+		}
+		if err := validateContact(fmt.Sprintf("deliveries[%d].toContact", i), d.Contact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateContact(field string, c Contact) error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("%s.name: %w", field, errRequiredField)
+	}
+	if strings.TrimSpace(c.Phone) == "" {
+		return fmt.Errorf("%s.phone: %w", field, errRequiredField)
+	}
+	return nil
+}
+
+// Validate checks the changes against the rules of GetQuotationRequest.Validate. Deliveries given
+// without Stops are checked against the maximum number of stops.
+func (r *EditOrderRequest) Validate() error {
+	if len(r.Stops) == 0 && len(r.Deliveries) == 0 && r.RequesterContact == nil {
+		return errRequiredField
+	}
+	stops := maxStops
+	if len(r.Stops) > 0 {
+		if err := validateStops(r.Stops); err != nil {
+			return err
+		}
+		stops = len(r.Stops)
+	}
+	if len(r.Stops) > 0 || len(r.Deliveries) > 0 {
+		if err := validateDeliveries(r.Deliveries, stops); err != nil {
+			return err
+		}
+	}
+	if r.RequesterContact != nil {
+		return validateContact("requesterContact", *r.RequesterContact)
+	}
+	return nil
+}
+
+func validateWebhookURL(webhookURL string) error {
+	if len(webhookURL) > maxWebhookURLLength {
+		return fmt.Errorf("webhook url longer than %d characters: %w", maxWebhookURLLength, errInvalidParams)
+	}
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return fmt.Errorf("webhook url: %s: %w", err, errInvalidParams)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("webhook url must be an absolute https URL: %w", errInvalidParams)
+	}
+	if u.User != nil || u.Fragment != "" {
+		return fmt.Errorf("webhook url must not contain credentials or a fragment: %w", errInvalidParams)
+	}
+	return nil
+}
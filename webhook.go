@@ -0,0 +1,140 @@
+package lalamove
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// maxWebhookBodySize is the largest webhook body accepted by WebhookHandler.
+const maxWebhookBodySize = 1 << 20
+
+var errInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// WebhookEventType ...
+type WebhookEventType string
+
+// WebhookEventType enum
+const (
+	// WebhookEventOrderStatusChanged - The status of an order changed.
+	WebhookEventOrderStatusChanged WebhookEventType = "ORDER_STATUS_CHANGED"
+	// WebhookEventDriverAssigned - A driver was assigned to an order.
+	WebhookEventDriverAssigned WebhookEventType = "DRIVER_ASSIGNED"
+	// WebhookEventOrderAmountChanged - The price of an order changed, e.g. after adding a priority fee.
+	WebhookEventOrderAmountChanged WebhookEventType = "ORDER_AMOUNT_CHANGED"
+)
+
+// WebhookEvent is a push update sent by Lalamove to the registered webhook.
+type WebhookEvent struct {
+	EventID      string           `json:"eventId"`
+	EventType    WebhookEventType `json:"eventType"`
+	EventVersion string           `json:"eventVersion"`
+	// Timestamp is when the event happened, in milliseconds since the Unix epoch.
+	Timestamp int64  `json:"timestamp"`
+	APIKey    string `json:"apiKey"`
+	// Signature is the HMAC of the timestamp, the webhook path and Data, signed with the API secret.
+	Signature string          `json:"signature"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookOrder is the order carried by order events.
+type WebhookOrder struct {
+	OrderID  string      `json:"orderId"`
+	Status   OrderStatus `json:"status,omitempty"`
+	DriverID string      `json:"driverId,omitempty"`
+	Price    *Price      `json:"price,omitempty"`
+}
+
+// WebhookOrderData is the data of order events.
+type WebhookOrderData struct {
+	Order WebhookOrder `json:"order"`
+}
+
+// Time returns the time of the event.
+func (e *WebhookEvent) Time() time.Time {
+	return time.Unix(0, e.Timestamp*int64(time.Millisecond))
+}
+
+// Order decodes the order carried by the event, keeping enum values unknown to this package as
+// they are.
+func (e *WebhookEvent) Order() (*WebhookOrder, error) {
+	return e.DecodeOrder(EnumDecodingLenient, nil)
+}
+
+// DecodeOrder decodes the order carried by the event, treating enum values unknown to this package
+// as a Client configured WithEnumDecoding and WithUnknownEnumHandler does. In strict mode, the
+// decoded order is returned along with the UnknownEnumError.
+func (e *WebhookEvent) DecodeOrder(mode EnumDecoding, onUnknown func(typ, value string)) (*WebhookOrder, error) {
+	data := &WebhookOrderData{}
+	if err := json.Unmarshal(e.Data, data); err != nil {
+		return nil, err
+	}
+	return &data.Order, checkEnums(data, mode, onUnknown)
+}
+
+// Sign sets the signature of the event for delivery to the given webhook path.
+func (e *WebhookEvent) Sign(secret, path string) {
+	e.Signature = sign(secret, e.Timestamp, http.MethodPost, path, e.Data)
+}
+
+// Verify checks the API key and signature of the event received on the given webhook path.
+func (e *WebhookEvent) Verify(apiKey, secret, path string) error {
+	if e.APIKey != apiKey {
+		return errInvalidWebhookSignature
+	}
+	expected := sign(secret, e.Timestamp, http.MethodPost, path, e.Data)
+	if !hmac.Equal([]byte(expected), []byte(e.Signature)) {
+		return errInvalidWebhookSignature
+	}
+	return nil
+}
+
+// WebhookHandler is a http.Handler receiving webhook events. Events with an invalid signature are
+// refused with 401; events Handle fails to process are answered with 500 so Lalamove retries them.
+type WebhookHandler struct {
+	APIKey string
+	Secret string
+	Handle func(ctx context.Context, ev *WebhookEvent) error
+}
+
+// NewWebhookHandler constructs a WebhookHandler.
+func NewWebhookHandler(apiKey, secret string, handle func(ctx context.Context, ev *WebhookEvent) error) *WebhookHandler {
+	return &WebhookHandler{APIKey: apiKey, Secret: secret, Handle: handle}
+}
+
+// ServeHTTP implements http.Handler. Empty requests, sent by Lalamove to check the webhook when it
+// is registered, are answered with 200.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	ev := &WebhookEvent{}
+	if err := json.Unmarshal(body, ev); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := ev.Verify(h.APIKey, h.Secret, r.URL.Path); err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	if err := h.Handle(r.Context(), ev); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,299 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultReorderWindow is how long status events are held back to be put in order.
+const defaultReorderWindow = 2 * time.Second
+
+// defaultSeenTTL is how long MemorySeenStore remembers event IDs.
+const defaultSeenTTL = 24 * time.Hour
+
+// defaultTerminalTTL is how long a WebhookProcessor remembers orders in a terminal status.
+const defaultTerminalTTL = 24 * time.Hour
+
+// ErrDuplicateEvent is passed to WebhookProcessorConfig.OnDiscard for events already processed.
+var ErrDuplicateEvent = errors.New("duplicate webhook event")
+
+var errProcessorClosed = errors.New("webhook processor closed")
+
+// SeenStore remembers the IDs of processed webhook events.
+type SeenStore interface {
+	// MarkSeen records the event ID and reports whether it had been recorded before.
+	MarkSeen(ctx context.Context, eventID string) (bool, error)
+	// Forget removes the event ID, so that an event which could not be processed is accepted when
+	// it is delivered again.
+	Forget(ctx context.Context, eventID string) error
+}
+
+// MemorySeenStore is a SeenStore kept in memory, forgetting event IDs after a TTL.
+type MemorySeenStore struct {
+	ttl   time.Duration
+	mu    sync.Mutex
+	cache *ttlCache
+}
+
+// NewMemorySeenStore constructs a MemorySeenStore remembering event IDs for ttl, 24 hours if zero.
+func NewMemorySeenStore(ttl time.Duration) *MemorySeenStore {
+	if ttl <= 0 {
+		ttl = defaultSeenTTL
+	}
+	return &MemorySeenStore{ttl: ttl, cache: newTTLCache(time.Now)}
+}
+
+// MarkSeen implements SeenStore.
+func (s *MemorySeenStore) MarkSeen(ctx context.Context, eventID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.cache.get(eventID); ok {
+		return true, nil
+	}
+	s.cache.set(eventID, struct{}{}, s.ttl)
+	return false, nil
+}
+
+// Forget implements SeenStore.
+func (s *MemorySeenStore) Forget(ctx context.Context, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache.delete(eventID)
+	return nil
+}
+
+// WebhookProcessorConfig configures a WebhookProcessor.
+type WebhookProcessorConfig struct {
+	// Seen deduplicates events. Defaults to a MemorySeenStore. A MemorySeenStore expires event IDs
+	// by Now.
+	Seen SeenStore
+	// Window is how long status events of an order are held back to be put in order.
+	// Defaults to 2 seconds.
+	Window time.Duration
+	// StateMachine decides which status changes are legal. Defaults to DefaultOrderStateMachine.
+	StateMachine *OrderStateMachine
+	// TerminalTTL is how long orders which reached a terminal status are remembered, to discard
+	// their late events as stale. Defaults to 24 hours.
+	TerminalTTL time.Duration
+	// OnTransition receives the status changes of every order, in order. Calls are never concurrent.
+	OnTransition func(Transition)
+	// OnEvent receives the deduplicated events which do not change the status of an order.
+	OnEvent func(*WebhookEvent)
+	// OnDiscard, if set, receives the events which were dropped as duplicate, stale or illegal, for
+	// carrying an unknown status in strict mode, or for carrying an order which could not be decoded.
+	OnDiscard func(ev *WebhookEvent, err error)
+	// EnumDecoding and OnUnknownEnum treat enum values unknown to this package in the orders of
+	// status events as WithEnumDecoding and WithUnknownEnumHandler do for a Client.
+	EnumDecoding  EnumDecoding
+	OnUnknownEnum func(typ, value string)
+	// Now is the source of the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// WebhookProcessor turns webhook events, which may be delivered more than once and out of order,
+// into a monotonic stream of status transitions per order. Its Process method can be used as
+// WebhookHandler.Handle.
+type WebhookProcessor struct {
+	cfg     WebhookProcessorConfig
+	tracker *OrderTracker
+	// terminal holds the final transition of the orders which reached a terminal status, after
+	// they are forgotten by the tracker.
+	terminal *ttlCache
+
+	mu      sync.Mutex
+	pending map[string][]pendingEvent
+	timers  map[string]*time.Timer
+	closed  bool
+	// inFlight holds the orders whose events are being delivered; flushed is signaled when one of
+	// them is done, so that a later flush of the same order waits and delivers after it.
+	inFlight map[string]bool
+	flushed  *sync.Cond
+
+	deliverMu sync.Mutex
+}
+
+type pendingEvent struct {
+	ev         *WebhookEvent
+	status     OrderStatus
+	receivedAt time.Time
+}
+
+// NewWebhookProcessor constructs a WebhookProcessor.
+func NewWebhookProcessor(cfg WebhookProcessorConfig) *WebhookProcessor {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.Seen == nil {
+		cfg.Seen = NewMemorySeenStore(0)
+	}
+	if m, ok := cfg.Seen.(*MemorySeenStore); ok {
+		m.mu.Lock()
+		m.cache.now = cfg.Now
+		m.mu.Unlock()
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultReorderWindow
+	}
+	if cfg.TerminalTTL <= 0 {
+		cfg.TerminalTTL = defaultTerminalTTL
+	}
+	p := &WebhookProcessor{
+		cfg:      cfg,
+		tracker:  NewOrderTracker(cfg.StateMachine),
+		terminal: newTTLCache(cfg.Now),
+		pending:  map[string][]pendingEvent{},
+		timers:   map[string]*time.Timer{},
+		inFlight: map[string]bool{},
+	}
+	p.flushed = sync.NewCond(&p.mu)
+	return p
+}
+
+// Process deduplicates the event and, for status changes, buffers it until the reorder window of
+// its order elapses. An event which is not buffered because of an error is not marked as seen, so
+// that it is processed when delivered again. Status events whose order cannot be decoded are
+// dropped, as delivering them again would not help.
+func (p *WebhookProcessor) Process(ctx context.Context, ev *WebhookEvent) error {
+	if ev.EventType != WebhookEventOrderStatusChanged {
+		seen, err := p.cfg.Seen.MarkSeen(ctx, ev.EventID)
+		if err != nil {
+			return err
+		}
+		if seen {
+			p.discard(ev, ErrDuplicateEvent)
+			return nil
+		}
+		if p.cfg.OnEvent != nil {
+			p.deliverMu.Lock()
+			p.cfg.OnEvent(ev)
+			p.deliverMu.Unlock()
+		}
+		return nil
+	}
+	order, err := ev.DecodeOrder(p.cfg.EnumDecoding, p.cfg.OnUnknownEnum)
+	if err != nil {
+		// Delivering the event again would not make its order decodable or its status known.
+		p.discard(ev, err)
+		return nil
+	}
+	if p.isClosed() {
+		return errProcessorClosed
+	}
+	seen, err := p.cfg.Seen.MarkSeen(ctx, ev.EventID)
+	if err != nil {
+		return err
+	}
+	if seen {
+		p.discard(ev, ErrDuplicateEvent)
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		if err := p.cfg.Seen.Forget(ctx, ev.EventID); err != nil {
+			return err
+		}
+		return errProcessorClosed
+	}
+	p.pending[order.OrderID] = append(p.pending[order.OrderID], pendingEvent{ev: ev, status: order.Status, receivedAt: p.cfg.Now()})
+	if _, ok := p.timers[order.OrderID]; !ok {
+		orderID := order.OrderID
+		p.timers[orderID] = time.AfterFunc(p.cfg.Window, func() { p.flushOrder(orderID) })
+	}
+	return nil
+}
+
+// Flush delivers every buffered event without waiting for the reorder window.
+func (p *WebhookProcessor) Flush() {
+	p.mu.Lock()
+	orderIDs := make([]string, 0, len(p.pending))
+	for orderID := range p.pending {
+		orderIDs = append(orderIDs, orderID)
+	}
+	p.mu.Unlock()
+	for _, orderID := range orderIDs {
+		p.flushOrder(orderID)
+	}
+}
+
+func (p *WebhookProcessor) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// Close flushes the buffered events and refuses new ones.
+func (p *WebhookProcessor) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.Flush()
+}
+
+func (p *WebhookProcessor) flushOrder(orderID string) {
+	p.mu.Lock()
+	for p.inFlight[orderID] {
+		p.flushed.Wait()
+	}
+	events := p.pending[orderID]
+	delete(p.pending, orderID)
+	if t, ok := p.timers[orderID]; ok {
+		t.Stop()
+		delete(p.timers, orderID)
+	}
+	if len(events) == 0 {
+		p.mu.Unlock()
+		return
+	}
+	p.inFlight[orderID] = true
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.inFlight, orderID)
+		p.flushed.Broadcast()
+		p.mu.Unlock()
+	}()
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].ev.Timestamp < events[j].ev.Timestamp
+	})
+	p.deliverMu.Lock()
+	defer p.deliverMu.Unlock()
+	for _, pe := range events {
+		if final, ok := p.terminal.get(orderID); ok {
+			t := final.(Transition)
+			if t.To == pe.status {
+				continue
+			}
+			p.discard(pe.ev, &TransitionError{
+				Transition: Transition{OrderID: orderID, From: t.To, To: pe.status, At: pe.ev.Time(), ObservedAt: pe.receivedAt},
+				Err:        ErrStaleTransition,
+			})
+			continue
+		}
+		transitions, err := p.tracker.Observe(orderID, pe.status, pe.ev.Time(), pe.receivedAt)
+		if err != nil {
+			p.discard(pe.ev, err)
+			continue
+		}
+		if p.cfg.OnTransition != nil {
+			for _, t := range transitions {
+				p.cfg.OnTransition(t)
+			}
+		}
+		if latest, ok := p.tracker.Latest(orderID); ok && p.tracker.machine.IsTerminal(latest.To) {
+			p.terminal.set(orderID, latest, p.cfg.TerminalTTL)
+			p.tracker.Forget(orderID)
+		}
+	}
+}
+
+func (p *WebhookProcessor) discard(ev *WebhookEvent, err error) {
+	if p.cfg.OnDiscard != nil {
+		p.cfg.OnDiscard(ev, err)
+	}
+}
@@ -0,0 +1,273 @@
+package lalamove
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// processorRecorder collects what a WebhookProcessor delivers.
+type processorRecorder struct {
+	mu          sync.Mutex
+	transitions []Transition
+	events      []*WebhookEvent
+	discarded   []error
+}
+
+func (r *processorRecorder) config() WebhookProcessorConfig {
+	return WebhookProcessorConfig{
+		Window: time.Hour,
+		OnTransition: func(t Transition) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.transitions = append(r.transitions, t)
+		},
+		OnEvent: func(ev *WebhookEvent) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.events = append(r.events, ev)
+		},
+		OnDiscard: func(ev *WebhookEvent, err error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.discarded = append(r.discarded, err)
+		},
+	}
+}
+
+func (r *processorRecorder) statuses(orderID string) []OrderStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var statuses []OrderStatus
+	for _, t := range r.transitions {
+		if t.OrderID == orderID {
+			statuses = append(statuses, t.To)
+		}
+	}
+	return statuses
+}
+
+func processAll(t *testing.T, p *WebhookProcessor, events ...*WebhookEvent) {
+	t.Helper()
+	for _, ev := range events {
+		if err := p.Process(context.Background(), ev); err != nil {
+			t.Fatalf("Process(%s) = %v", ev.EventID, err)
+		}
+	}
+}
+
+func TestWebhookProcessorReorders(t *testing.T) {
+	r := &processorRecorder{}
+	p := NewWebhookProcessor(r.config())
+	defer p.Close()
+	processAll(t, p,
+		testEvent("e3", "1001", OrderStatusPickedUp, 3),
+		testEvent("e1", "1001", OrderStatusAssigningDriver, 1),
+		testEvent("e2", "1001", OrderStatusOngoing, 2),
+		testEvent("e2", "1001", OrderStatusOngoing, 2),
+	)
+	if got := r.statuses("1001"); len(got) != 0 {
+		t.Fatalf("delivered %v before the reorder window elapsed", got)
+	}
+	p.Flush()
+	want := []OrderStatus{OrderStatusAssigningDriver, OrderStatusOngoing, OrderStatusPickedUp}
+	if got := r.statuses("1001"); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("transitions = %v, want %v", got, want)
+	}
+	if len(r.discarded) != 1 || !errors.Is(r.discarded[0], ErrDuplicateEvent) {
+		t.Errorf("discarded %v, want the duplicate", r.discarded)
+	}
+}
+
+func TestWebhookProcessorWindow(t *testing.T) {
+	r := &processorRecorder{}
+	cfg := r.config()
+	cfg.Window = time.Millisecond
+	p := NewWebhookProcessor(cfg)
+	defer p.Close()
+	processAll(t, p, testEvent("e1", "1001", OrderStatusOngoing, 1))
+	for i := 0; i < 1000 && len(r.statuses("1001")) == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if got := r.statuses("1001"); len(got) != 1 {
+		t.Errorf("transitions = %v, want the event delivered once the window elapsed", got)
+	}
+}
+
+func TestWebhookProcessorInfersAndDiscards(t *testing.T) {
+	r := &processorRecorder{}
+	p := NewWebhookProcessor(r.config())
+	defer p.Close()
+	processAll(t, p,
+		testEvent("e1", "1001", OrderStatusAssigningDriver, 1),
+		testEvent("e2", "1001", OrderStatusCompleted, 4),
+	)
+	p.Flush()
+	r.mu.Lock()
+	if len(r.transitions) != 4 || !r.transitions[1].Inferred || !r.transitions[2].Inferred || r.transitions[3].Inferred {
+		t.Errorf("transitions = %+v, want ON_GOING and PICKED_UP inferred before COMPLETED", r.transitions)
+	}
+	r.mu.Unlock()
+
+	// Late events of the completed order are stale, repeated terminal ones are ignored.
+	processAll(t, p,
+		testEvent("e3", "1001", OrderStatusPickedUp, 3),
+		testEvent("e4", "1001", OrderStatusCompleted, 5),
+	)
+	p.Flush()
+	if len(r.discarded) != 1 || !errors.Is(r.discarded[0], ErrStaleTransition) {
+		t.Errorf("discarded %v, want the late event as stale", r.discarded)
+	}
+	if got := r.statuses("1001"); len(got) != 4 {
+		t.Errorf("transitions = %v, want nothing more after COMPLETED", got)
+	}
+}
+
+func TestWebhookProcessorOtherEvents(t *testing.T) {
+	r := &processorRecorder{}
+	p := NewWebhookProcessor(r.config())
+	defer p.Close()
+	ev := testEvent("e1", "1001", "", 1)
+	ev.EventType = WebhookEventDriverAssigned
+	processAll(t, p, ev, ev)
+	if len(r.events) != 1 || r.events[0] != ev {
+		t.Errorf("events = %v, want the event once", r.events)
+	}
+	if len(r.discarded) != 1 || !errors.Is(r.discarded[0], ErrDuplicateEvent) {
+		t.Errorf("discarded %v, want the duplicate", r.discarded)
+	}
+}
+
+func TestWebhookProcessorDropsUndecodableOrders(t *testing.T) {
+	r := &processorRecorder{}
+	p := NewWebhookProcessor(r.config())
+	defer p.Close()
+	ev := testEvent("e1", "1001", OrderStatusOngoing, 1)
+	ev.Data = json.RawMessage(`{"order":"1001"}`)
+	if err := p.Process(context.Background(), ev); err != nil {
+		t.Errorf("Process() = %v, want the event acknowledged", err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if len(r.discarded) != 1 || !errors.As(r.discarded[0], &typeErr) {
+		t.Errorf("discarded %v, want the decoding error", r.discarded)
+	}
+}
+
+func TestWebhookProcessorSeenStoreErrors(t *testing.T) {
+	cfg := (&processorRecorder{}).config()
+	cfg.Seen = failingSeenStore{}
+	p := NewWebhookProcessor(cfg)
+	defer p.Close()
+	if err := p.Process(context.Background(), testEvent("e1", "1001", OrderStatusOngoing, 1)); !errors.Is(err, errStoreUnavailable) {
+		t.Errorf("Process() = %v, want %v so the event is delivered again", err, errStoreUnavailable)
+	}
+}
+
+type failingSeenStore struct{}
+
+func (failingSeenStore) MarkSeen(ctx context.Context, eventID string) (bool, error) {
+	return false, errStoreUnavailable
+}
+
+func (failingSeenStore) Forget(ctx context.Context, eventID string) error {
+	return errStoreUnavailable
+}
+
+func TestWebhookProcessorClose(t *testing.T) {
+	r := &processorRecorder{}
+	p := NewWebhookProcessor(r.config())
+	processAll(t, p, testEvent("e1", "1001", OrderStatusOngoing, 1))
+	p.Close()
+	if got := r.statuses("1001"); len(got) != 1 {
+		t.Errorf("transitions = %v, want the buffered event delivered on Close", got)
+	}
+	if err := p.Process(context.Background(), testEvent("e2", "1001", OrderStatusPickedUp, 2)); !errors.Is(err, errProcessorClosed) {
+		t.Errorf("Process() after Close = %v, want %v", err, errProcessorClosed)
+	}
+}
+
+func TestWebhookProcessorSeenStoreClock(t *testing.T) {
+	now := testNow
+	var mu sync.Mutex
+	cfg := (&processorRecorder{}).config()
+	cfg.Now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	cfg.Seen = NewMemorySeenStore(time.Hour)
+	var duplicates int
+	cfg.OnDiscard = func(ev *WebhookEvent, err error) {
+		if errors.Is(err, ErrDuplicateEvent) {
+			duplicates++
+		}
+	}
+	p := NewWebhookProcessor(cfg)
+	defer p.Close()
+	ev := testEvent("e1", "1001", "", 1)
+	ev.EventType = WebhookEventDriverAssigned
+	processAll(t, p, ev, ev)
+	mu.Lock()
+	now = now.Add(2 * time.Hour)
+	mu.Unlock()
+	processAll(t, p, ev)
+	if duplicates != 1 {
+		t.Errorf("%d duplicates, want the event forgotten once its ID expired by the processor clock", duplicates)
+	}
+}
+
+func TestWebhookProcessorConcurrentFlushesKeepOrder(t *testing.T) {
+	r := &processorRecorder{}
+	cfg := r.config()
+	delivering := make(chan struct{})
+	release := make(chan struct{})
+	onTransition := cfg.OnTransition
+	cfg.OnTransition = func(tr Transition) {
+		if tr.To == OrderStatusOngoing {
+			close(delivering)
+			<-release
+		}
+		onTransition(tr)
+	}
+	p := NewWebhookProcessor(cfg)
+	defer p.Close()
+
+	processAll(t, p, testEvent("e1", "1001", OrderStatusOngoing, 1))
+	first := make(chan struct{})
+	go func() {
+		p.Flush()
+		close(first)
+	}()
+	<-delivering
+
+	// A flush of the same order while its earlier events are delivered must wait for them, and
+	// leave the later event buffered until then.
+	processAll(t, p, testEvent("e2", "1001", OrderStatusPickedUp, 2))
+	second := make(chan struct{})
+	go func() {
+		p.Flush()
+		close(second)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	p.mu.Lock()
+	buffered := len(p.pending["1001"])
+	p.mu.Unlock()
+	if buffered != 1 {
+		t.Errorf("%d events buffered while the order is delivered, want the later one left buffered", buffered)
+	}
+	close(release)
+	<-first
+	<-second
+
+	want := []OrderStatus{OrderStatusOngoing, OrderStatusPickedUp}
+	if got := r.statuses("1001"); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("transitions = %v, want %v", got, want)
+	}
+	if len(r.discarded) != 0 {
+		t.Errorf("discarded %v, want none", r.discarded)
+	}
+}
@@ -0,0 +1,179 @@
+package lalamove
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/twinj/uuid"
+)
+
+// DeliveryKind describes how a simulated webhook event was delivered.
+type DeliveryKind string
+
+// DeliveryKind enum
+const (
+	// DeliveryNormal - The event was delivered once, on time.
+	DeliveryNormal DeliveryKind = "normal"
+	// DeliveryDuplicate - The event was delivered again.
+	DeliveryDuplicate DeliveryKind = "duplicate"
+	// DeliveryDelayed - The event was delivered late, possibly after later events.
+	DeliveryDelayed DeliveryKind = "delayed"
+	// DeliveryMalformed - A body which is not valid JSON was delivered.
+	DeliveryMalformed DeliveryKind = "malformed"
+	// DeliveryBadSignature - The event was delivered with an invalid signature.
+	DeliveryBadSignature DeliveryKind = "bad-signature"
+)
+
+// SimulatedDelivery is the outcome of one delivery of the simulator.
+type SimulatedDelivery struct {
+	Kind       DeliveryKind
+	Event      *WebhookEvent
+	StatusCode int
+	Err        error
+}
+
+// SimulationOptions configures WebhookSimulator.Simulate. Rates are probabilities between 0 and 1.
+type SimulationOptions struct {
+	// DriverID is set on the events of orders which have a driver.
+	DriverID string
+	// Interval is the time between status changes. Events are delivered in real time.
+	Interval time.Duration
+	// DuplicateRate is the probability of an event being delivered twice.
+	DuplicateRate float64
+	// DelayRate is the probability of an event being delayed by up to MaxDelay.
+	DelayRate float64
+	MaxDelay  time.Duration
+	// MalformedRate is the probability of a malformed body or a bad signature being delivered
+	// alongside an event.
+	MalformedRate float64
+	// Seed makes the random choices reproducible.
+	Seed int64
+	// EnumDecoding set to EnumDecodingStrict refuses statuses unknown to this package with an
+	// UnknownEnumError instead of sending them.
+	EnumDecoding EnumDecoding
+}
+
+// WebhookSimulator sends correctly signed webhook events to a local URL, to exercise webhook
+// handlers without exposing them to Lalamove.
+type WebhookSimulator struct {
+	URL        string
+	APIKey     string
+	Secret     string
+	HTTPClient *http.Client
+}
+
+// Simulate delivers one ORDER_STATUS_CHANGED event per status, in sequence, with duplicate, delayed
+// and malformed deliveries mixed in according to the options. It returns every delivery in the
+// order it was sent.
+func (s *WebhookSimulator) Simulate(ctx context.Context, orderID string, statuses []OrderStatus, opts SimulationOptions) ([]SimulatedDelivery, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEnums(statuses, opts.EnumDecoding, nil); err != nil {
+		return nil, err
+	}
+	rnd := rand.New(rand.NewSource(opts.Seed))
+	type scheduled struct {
+		at   time.Duration
+		kind DeliveryKind
+		ev   *WebhookEvent
+	}
+	var plan []scheduled
+	start := time.Now()
+	driverID := ""
+	for i, status := range statuses {
+		if status == OrderStatusOngoing {
+			driverID = opts.DriverID
+		}
+		ev, err := s.event(orderID, status, driverID, start.Add(time.Duration(i)*opts.Interval), u.Path)
+		if err != nil {
+			return nil, err
+		}
+		at := time.Duration(i) * opts.Interval
+		kind := DeliveryNormal
+		if opts.MaxDelay > 0 && rnd.Float64() < opts.DelayRate {
+			at += time.Duration(rnd.Int63n(int64(opts.MaxDelay))) + 1
+			kind = DeliveryDelayed
+		}
+		plan = append(plan, scheduled{at: at, kind: kind, ev: ev})
+		if rnd.Float64() < opts.DuplicateRate {
+			plan = append(plan, scheduled{at: at, kind: DeliveryDuplicate, ev: ev})
+		}
+		if rnd.Float64() < opts.MalformedRate {
+			bad := *ev
+			kind := DeliveryBadSignature
+			if rnd.Intn(2) == 0 {
+				kind = DeliveryMalformed
+			} else {
+				bad.Signature = sign("not-the-secret", bad.Timestamp, http.MethodPost, u.Path, bad.Data)
+			}
+			plan = append(plan, scheduled{at: at, kind: kind, ev: &bad})
+		}
+	}
+	sort.SliceStable(plan, func(i, j int) bool { return plan[i].at < plan[j].at })
+
+	var deliveries []SimulatedDelivery
+	for _, p := range plan {
+		if wait := p.at - time.Since(start); wait > 0 {
+			t := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return deliveries, ctx.Err()
+			case <-t.C:
+			}
+		}
+		status, err := s.deliver(ctx, p.ev, p.kind == DeliveryMalformed)
+		deliveries = append(deliveries, SimulatedDelivery{Kind: p.kind, Event: p.ev, StatusCode: status, Err: err})
+	}
+	return deliveries, nil
+}
+
+func (s *WebhookSimulator) event(orderID string, status OrderStatus, driverID string, at time.Time, path string) (*WebhookEvent, error) {
+	data, err := json.Marshal(WebhookOrderData{Order: WebhookOrder{OrderID: orderID, Status: status, DriverID: driverID}})
+	if err != nil {
+		return nil, err
+	}
+	ev := &WebhookEvent{
+		EventID:      uuid.NewV4().String(),
+		EventType:    WebhookEventOrderStatusChanged,
+		EventVersion: "v2",
+		Timestamp:    at.UnixNano() / int64(time.Millisecond),
+		APIKey:       s.APIKey,
+		Data:         data,
+	}
+	ev.Sign(s.Secret, path)
+	return ev, nil
+}
+
+func (s *WebhookSimulator) deliver(ctx context.Context, ev *WebhookEvent, malformed bool) (int, error) {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return 0, err
+	}
+	if malformed {
+		body = body[:len(body)/2]
+	}
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
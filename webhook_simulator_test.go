@@ -0,0 +1,145 @@
+package lalamove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var simulatedStatuses = []OrderStatus{OrderStatusAssigningDriver, OrderStatusOngoing, OrderStatusPickedUp, OrderStatusCompleted}
+
+// simulatorTarget serves a WebhookHandler feeding a WebhookProcessor.
+func simulatorTarget(t *testing.T) (*WebhookSimulator, *WebhookProcessor, *processorRecorder) {
+	t.Helper()
+	r := &processorRecorder{}
+	p := NewWebhookProcessor(r.config())
+	srv := httptest.NewServer(NewWebhookHandler(testWebhookAPIKey, testWebhookSecret, p.Process))
+	t.Cleanup(func() {
+		srv.Close()
+		p.Close()
+	})
+	sim := &WebhookSimulator{URL: srv.URL + testWebhookPath, APIKey: testWebhookAPIKey, Secret: testWebhookSecret, HTTPClient: srv.Client()}
+	return sim, p, r
+}
+
+func TestSimulate(t *testing.T) {
+	sim, p, r := simulatorTarget(t)
+	deliveries, err := sim.Simulate(context.Background(), "1001", simulatedStatuses, SimulationOptions{DriverID: "21712", Interval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != len(simulatedStatuses) {
+		t.Fatalf("%d deliveries, want one per status", len(deliveries))
+	}
+	for i, d := range deliveries {
+		if d.Kind != DeliveryNormal || d.StatusCode != http.StatusOK || d.Err != nil {
+			t.Errorf("delivery %d = %s %d %v, want a normal delivery accepted", i, d.Kind, d.StatusCode, d.Err)
+		}
+		order, err := d.Event.Order()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if order.OrderID != "1001" || order.Status != simulatedStatuses[i] {
+			t.Errorf("delivery %d carries %+v, want order 1001 %s", i, order, simulatedStatuses[i])
+		}
+		if hasDriver := order.DriverID == "21712"; hasDriver != (i > 0) {
+			t.Errorf("delivery %d of %s has driver %q", i, order.Status, order.DriverID)
+		}
+		if i > 0 && d.Event.Timestamp < deliveries[i-1].Event.Timestamp {
+			t.Errorf("delivery %d is timestamped before the previous one", i)
+		}
+	}
+	p.Flush()
+	if got := r.statuses("1001"); fmt.Sprint(got) != fmt.Sprint(simulatedStatuses) {
+		t.Errorf("transitions = %v, want %v", got, simulatedStatuses)
+	}
+}
+
+func TestSimulateUnreliableDelivery(t *testing.T) {
+	sim, p, r := simulatorTarget(t)
+	opts := SimulationOptions{
+		Interval:      time.Millisecond,
+		DuplicateRate: 0.5,
+		DelayRate:     0.5,
+		MaxDelay:      5 * time.Millisecond,
+		MalformedRate: 0.5,
+		Seed:          7,
+	}
+	deliveries, err := sim.Simulate(context.Background(), "1001", simulatedStatuses, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCodes := map[DeliveryKind]int{
+		DeliveryNormal:       http.StatusOK,
+		DeliveryDuplicate:    http.StatusOK,
+		DeliveryDelayed:      http.StatusOK,
+		DeliveryMalformed:    http.StatusBadRequest,
+		DeliveryBadSignature: http.StatusUnauthorized,
+	}
+	kinds := map[DeliveryKind]int{}
+	for i, d := range deliveries {
+		kinds[d.Kind]++
+		if d.Err != nil || d.StatusCode != wantCodes[d.Kind] {
+			t.Errorf("delivery %d (%s) = %d %v, want %d", i, d.Kind, d.StatusCode, d.Err, wantCodes[d.Kind])
+		}
+	}
+	for kind := range wantCodes {
+		if kinds[kind] == 0 {
+			t.Errorf("no %s delivery with seed %d: %v", kind, opts.Seed, kinds)
+		}
+	}
+
+	// The same seed makes the same choices.
+	again, err := sim.Simulate(context.Background(), "1002", simulatedStatuses, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kindsOf := func(deliveries []SimulatedDelivery) []DeliveryKind {
+		var kinds []DeliveryKind
+		for _, d := range deliveries {
+			kinds = append(kinds, d.Kind)
+		}
+		return kinds
+	}
+	if got, want := kindsOf(again), kindsOf(deliveries); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("deliveries with the same seed = %v, want %v", got, want)
+	}
+
+	p.Flush()
+	if got := r.statuses("1001"); fmt.Sprint(got) != fmt.Sprint(simulatedStatuses) {
+		t.Errorf("transitions = %v, want %v despite duplicates, delays and bad deliveries", got, simulatedStatuses)
+	}
+}
+
+func TestSimulateCanceled(t *testing.T) {
+	sim, _, _ := simulatorTarget(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	deliveries, err := sim.Simulate(ctx, "1001", simulatedStatuses, SimulationOptions{Interval: time.Hour})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if len(deliveries) != 1 {
+		t.Errorf("%d deliveries, want only the first status delivered before the deadline", len(deliveries))
+	}
+}
+
+func TestSimulateUnreachable(t *testing.T) {
+	sim := &WebhookSimulator{URL: "http://localhost:1/webhook", HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})}}
+	deliveries, err := sim.Simulate(context.Background(), "1001", simulatedStatuses[:1], SimulationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Err == nil || deliveries[0].StatusCode != 0 {
+		t.Errorf("deliveries = %+v, want the failure reported on the delivery", deliveries)
+	}
+	if _, err := (&WebhookSimulator{URL: "://"}).Simulate(context.Background(), "1001", simulatedStatuses, SimulationOptions{}); err == nil {
+		t.Error("Simulate() with an invalid URL = nil, want an error")
+	}
+}
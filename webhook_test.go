@@ -0,0 +1,104 @@
+package lalamove
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	testWebhookAPIKey = "pk_test_0123456789"
+	testWebhookSecret = "sk_test_0123456789"
+	testWebhookPath   = "/lalamove/webhook"
+)
+
+// testEvent is a status event of the order signed for testWebhookPath.
+func testEvent(eventID, orderID string, status OrderStatus, timestamp int64) *WebhookEvent {
+	data, _ := json.Marshal(WebhookOrderData{Order: WebhookOrder{OrderID: orderID, Status: status}})
+	ev := &WebhookEvent{
+		EventID:      eventID,
+		EventType:    WebhookEventOrderStatusChanged,
+		EventVersion: "v3",
+		Timestamp:    timestamp,
+		APIKey:       testWebhookAPIKey,
+		Data:         data,
+	}
+	ev.Sign(testWebhookSecret, testWebhookPath)
+	return ev
+}
+
+func TestWebhookVerify(t *testing.T) {
+	ev := testEvent("e1", "1001", OrderStatusOngoing, testNow.UnixNano()/int64(1e6))
+	if err := ev.Verify(testWebhookAPIKey, testWebhookSecret, testWebhookPath); err != nil {
+		t.Errorf("Verify() = %v, want nil", err)
+	}
+	if !ev.Time().Equal(testNow) {
+		t.Errorf("Time() = %s, want %s", ev.Time(), testNow)
+	}
+	tests := []struct {
+		name   string
+		modify func(ev *WebhookEvent)
+		apiKey string
+		path   string
+	}{
+		{"other API key", func(ev *WebhookEvent) {}, "pk_test_other", testWebhookPath},
+		{"other path", func(ev *WebhookEvent) {}, testWebhookAPIKey, "/other"},
+		{"tampered data", func(ev *WebhookEvent) { ev.Data = json.RawMessage(`{"order":{"orderId":"1001","status":"COMPLETED"}}`) }, testWebhookAPIKey, testWebhookPath},
+		{"tampered timestamp", func(ev *WebhookEvent) { ev.Timestamp++ }, testWebhookAPIKey, testWebhookPath},
+		{"signed with another secret", func(ev *WebhookEvent) { ev.Sign("sk_test_other", testWebhookPath) }, testWebhookAPIKey, testWebhookPath},
+	}
+	for _, tt := range tests {
+		ev := testEvent("e1", "1001", OrderStatusOngoing, 1)
+		tt.modify(ev)
+		if err := ev.Verify(tt.apiKey, testWebhookSecret, tt.path); !errors.Is(err, errInvalidWebhookSignature) {
+			t.Errorf("Verify(%s) = %v, want %v", tt.name, err, errInvalidWebhookSignature)
+		}
+	}
+}
+
+func TestWebhookHandler(t *testing.T) {
+	signed, _ := json.Marshal(testEvent("e1", "1001", OrderStatusOngoing, 1))
+	unsigned := testEvent("e1", "1001", OrderStatusOngoing, 1)
+	unsigned.Signature = ""
+	unsignedBody, _ := json.Marshal(unsigned)
+	errHandle := errors.New("store unavailable")
+	tests := []struct {
+		name      string
+		method    string
+		body      string
+		handleErr error
+		want      int
+		handled   bool
+	}{
+		{"signed event", http.MethodPost, string(signed), nil, http.StatusOK, true},
+		{"failing handler", http.MethodPost, string(signed), errHandle, http.StatusInternalServerError, true},
+		{"registration check", http.MethodPost, "", nil, http.StatusOK, false},
+		{"blank registration check", http.MethodPost, " \n", nil, http.StatusOK, false},
+		{"wrong method", http.MethodGet, string(signed), nil, http.StatusMethodNotAllowed, false},
+		{"malformed event", http.MethodPost, `{"eventId":`, nil, http.StatusBadRequest, false},
+		{"unsigned event", http.MethodPost, string(unsignedBody), nil, http.StatusUnauthorized, false},
+		{"too large", http.MethodPost, `{"data":"` + strings.Repeat("x", maxWebhookBodySize) + `"}`, nil, http.StatusRequestEntityTooLarge, false},
+	}
+	for _, tt := range tests {
+		var handled *WebhookEvent
+		h := NewWebhookHandler(testWebhookAPIKey, testWebhookSecret, func(ctx context.Context, ev *WebhookEvent) error {
+			handled = ev
+			return tt.handleErr
+		})
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, testWebhookPath, strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+		if (handled != nil) != tt.handled {
+			t.Errorf("%s: handled = %v, want %v", tt.name, handled != nil, tt.handled)
+		}
+		if handled != nil && handled.EventID != "e1" {
+			t.Errorf("%s: handled event %s, want e1", tt.name, handled.EventID)
+		}
+	}
+}